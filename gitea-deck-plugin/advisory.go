@@ -0,0 +1,183 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// alternateWinConditions are noncreature cards that win the game on their
+// own, so a deck running them isn't missing a win condition.
+var alternateWinConditions = []string{
+	"Approach of the Second Sun",
+	"Barren Glory",
+	"Battle of Wits",
+	"Coalition Victory",
+	"Door to Nothingness",
+	"Helix Pinnacle",
+	"Maze's End",
+	"Mechanized Production",
+	"Revel in Riches",
+	"Test of Endurance",
+}
+
+// directDamageCards are burn spells that can finish a game by dealing
+// damage to players, for decks without type annotations that say so.
+var directDamageCards = []string{
+	"Banefire", "Boros Charm", "Chain Lightning", "Char", "Exquisite Firecraft",
+	"Fireball", "Fireblast", "Flame Rift", "Incinerate", "Lava Dart",
+	"Lava Spike", "Lightning Bolt", "Lightning Helix", "Price of Progress",
+	"Rift Bolt", "Searing Blaze", "Shock", "Skewer the Critics",
+	"Stoke the Flames",
+}
+
+// hasWinCondition is a rough heuristic for whether an MTG deck can win: it
+// looks for creatures, planeswalkers, vehicles, direct damage or a known
+// alternate win condition. Type lines never say a spell deals damage, so
+// direct damage is a card on directDamageCards, or any card in a deck tagged
+// "burn". Decks without any type data get the benefit of the doubt.
+func hasWinCondition(deck *Deck) bool {
+	for _, tag := range deck.Metadata.Tags {
+		if strings.EqualFold(strings.TrimSpace(tag), "burn") {
+			return true
+		}
+	}
+	known := map[string]bool{}
+	for _, name := range append(append([]string{}, alternateWinConditions...), directDamageCards...) {
+		known[normalizeName(deck.Game, name)] = true
+	}
+
+	typed := false
+	for _, card := range append(commandersOf(deck), deck.Cards...) {
+		if known[normalizeName(deck.Game, card.Name)] {
+			return true
+		}
+		if card.Type == "" {
+			continue
+		}
+		typed = true
+		for _, t := range []string{"creature", "planeswalker", "vehicle"} {
+			if hasType(card, t) {
+				return true
+			}
+		}
+	}
+	return !typed
+}
+
+// checkLandRatio warns when the main deck's land count falls outside the
+// recommended band for a deck of size cards. It is skipped for an empty
+// main deck and when any card lacks the type data to tell whether it is a
+// land.
+func checkLandRatio(deck *Deck, size int, result *ValidationResult) {
+	band, ok := rules.LandBands[size]
+	if !ok {
+		return
+	}
+	lands, guessed := countLands(deck)
+	if guessed > 0 || len(deck.Cards) == 0 {
+		return
+	}
+	if lands < band.Min || lands > band.Max {
+		result.addWarning(CodeLandCount, "", lands, band.Min, band.Max)
+	}
+}
+
+// resourceCount returns the number of resource cards in the main deck and
+// what they are for the deck's game: lands for MTG, energy for Pokémon and
+// the rune deck for Riftbound. The count is -1 when some card lacks the
+// type data to tell, and the kind is empty for games without a resource.
+func resourceCount(deck *Deck) (int, string) {
+	switch deck.Game {
+	case "mtg":
+		lands, guessed := countLands(deck)
+		if guessed > 0 {
+			return -1, "lands"
+		}
+		return lands, "lands"
+	case "pokemon":
+		energy := 0
+		for _, card := range deck.Cards {
+			if card.Type == "" {
+				return -1, "energy"
+			}
+			if hasType(card, "energy") {
+				energy += card.Count
+			}
+		}
+		return energy, "energy"
+	case "riftbound":
+		runes := 0
+		for _, card := range deck.Runes {
+			runes += card.Count
+		}
+		return runes, "runes"
+	}
+	return 0, ""
+}
+
+// checkResourceFloor warns when the main deck has fewer resource cards than
+// the game's entry in rules.ResourceFloors. Riftbound is skipped since its
+// rune deck already has a fixed size, as are empty decks and decks whose
+// resources can't be counted.
+func checkResourceFloor(deck *Deck, result *ValidationResult) {
+	floor, ok := rules.ResourceFloors[deck.Game]
+	if !ok || deck.Game == "riftbound" || len(deck.Cards) == 0 {
+		return
+	}
+	count, kind := resourceCount(deck)
+	if count < 0 || kind == "" {
+		return
+	}
+	if count < floor {
+		result.addWarning(CodeResourceCount, "", count, kind, floor)
+	}
+}
+
+// themeTagPrefix marks a tag in Metadata.Tags as the deck's theme, e.g.
+// "theme:Elf".
+const themeTagPrefix = "theme:"
+
+// themeConsistency returns the share of the deck's nonland cards, commanders
+// included and weighted by copies, whose type line has theme as a whole
+// word. It is -1 when a nonland card has no type data or there are no
+// nonland cards.
+func themeConsistency(deck *Deck, theme string) float64 {
+	want := " " + strings.ToLower(strings.Join(strings.Fields(theme), " ")) + " "
+	matched, total := 0, 0
+	for _, card := range append(commandersOf(deck), deck.Cards...) {
+		if land, known := isLand(card); land || !known {
+			if !known {
+				return -1
+			}
+			continue
+		}
+		total += card.Count
+		words := strings.FieldsFunc(strings.ToLower(card.Type), func(r rune) bool {
+			return !unicode.IsLetter(r) && r != '\'' && r != '-'
+		})
+		if strings.Contains(" "+strings.Join(words, " ")+" ", want) {
+			matched += card.Count
+		}
+	}
+	if total == 0 {
+		return -1
+	}
+	return float64(matched) / float64(total)
+}
+
+// checkThemes warns for each "theme:" tag matched by less than
+// rules.ThemeMinShare of the nonland cards. Themes that can't be measured
+// are skipped.
+func checkThemes(deck *Deck, result *ValidationResult) {
+	for _, tag := range deck.Metadata.Tags {
+		prefix, theme, ok := strings.Cut(strings.TrimSpace(tag), ":")
+		if !ok || strings.ToLower(prefix)+":" != themeTagPrefix || strings.TrimSpace(theme) == "" {
+			continue
+		}
+		share := themeConsistency(deck, theme)
+		if share >= 0 && share < rules.ThemeMinShare {
+			result.addWarning(CodeThemeConsistency, "", int(share*100), strings.TrimSpace(theme), int(math.Round(rules.ThemeMinShare*100)))
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// exportArena renders the deck as an MTG Arena import list: sections headed
+// Commander, Companion, Deck and Sideboard, one "count name (SET)" line per
+// entry. The set is left off for cards without one.
+func exportArena(deck *Deck) (string, error) {
+	var b strings.Builder
+	section := func(title string, cards []DeckCard) {
+		if len(cards) == 0 {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(title + "\n")
+		for _, card := range cards {
+			if card.Set != "" {
+				fmt.Fprintf(&b, "%d %s (%s)\n", card.Count, displayName(card), strings.ToUpper(card.Set))
+			} else {
+				fmt.Fprintf(&b, "%d %s\n", card.Count, displayName(card))
+			}
+		}
+	}
+
+	section("Commander", commandersOf(deck))
+	if deck.Companion != nil {
+		section("Companion", []DeckCard{*deck.Companion})
+	}
+	section("Deck", deck.Cards)
+	section("Sideboard", deck.Sideboard)
+	return b.String(), nil
+}
+
+// arenaLine matches an Arena list entry: a count, the card name and an
+// optional set code and collector number.
+var arenaLine = regexp.MustCompile(`^(\d+)x?\s+(.+?)(?:\s+\(([A-Za-z0-9]+)\)(?:\s+\S+)?)?$`)
+
+// importArena parses an MTG Arena import list. Cards before any section
+// header, or under Deck, go in the main deck; a blank line after main deck
+// cards with no header starts the sideboard, as Arena itself does. A deck
+// with a Commander section is given the commander format.
+func importArena(data []byte) (*Deck, error) {
+	deck := &Deck{Game: "mtg"}
+	section := "deck"
+	headed := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch header := strings.ToLower(line); header {
+		case "":
+			if !headed && section == "deck" && len(deck.Cards) > 0 {
+				section = "sideboard"
+			}
+			continue
+		case "deck", "sideboard", "commander", "companion":
+			section, headed = header, true
+			continue
+		}
+
+		m := arenaLine.FindStringSubmatch(line)
+		if m == nil {
+			return nil, fmt.Errorf("line %d: expected \"<count> <card name>\", got %q", n, line)
+		}
+		count, _ := strconv.Atoi(m[1])
+		card := DeckCard{Count: count, Name: m[2], Set: m[3]}
+		switch section {
+		case "deck":
+			deck.Cards = append(deck.Cards, card)
+		case "sideboard":
+			deck.Sideboard = append(deck.Sideboard, card)
+		case "commander":
+			deck.Commanders = append(deck.Commanders, card)
+		case "companion":
+			deck.Companion = &card
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(deck.Cards) == 0 && len(deck.Commanders) == 0 {
+		return nil, errors.New("no cards found in Arena list")
+	}
+
+	if len(deck.Commanders) > 0 {
+		deck.Format = "commander"
+	}
+	if len(deck.Commanders) == 1 {
+		deck.Commander = &deck.Commanders[0]
+		deck.Commanders = nil
+	}
+	return deck, nil
+}
@@ -0,0 +1,23 @@
+package main
+
+import "strings"
+
+// enforceAuthor makes an author mismatch an error instead of a warning.
+var enforceAuthor bool
+
+// userHeader carries the authenticated Gitea user name when the plugin runs
+// behind Gitea.
+const userHeader = "X-Gitea-User"
+
+// checkAuthor compares the deck's Metadata.Author with the authenticated
+// user, if the request had one. Gitea user names are case-insensitive.
+func checkAuthor(deck *Deck, opts ValidateOptions, result *ValidationResult) {
+	if opts.User == "" || strings.EqualFold(deck.Metadata.Author, opts.User) {
+		return
+	}
+	if enforceAuthor {
+		result.addError(CodeAuthorMismatch, "", deck.Metadata.Author, opts.User)
+	} else {
+		result.addWarning(CodeAuthorMismatch, "", deck.Metadata.Author, opts.User)
+	}
+}
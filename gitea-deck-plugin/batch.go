@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// validateBatchHandler validates a JSON array of decks and returns one
+// ValidationResult per deck, in input order. Decks are decoded one at a
+// time. With stream=true each result is written as a line of NDJSON and
+// flushed as soon as it is ready, so memory stays bounded and clients see
+// progress; otherwise the results are returned as a JSON array. Card
+// entries are counted against maxCardEntries as each deck is decoded,
+// rather than up front, so the body is never buffered.
+func validateBatchHandler(w http.ResponseWriter, r *http.Request) {
+	dec := json.NewDecoder(r.Body)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		http.Error(w, "request body must be a JSON array of decks", http.StatusBadRequest)
+		return
+	}
+
+	opts := validateOptionsFromRequest(r)
+	locale := negotiateLocale(r.Header.Get("Accept-Language"))
+	stream := r.URL.Query().Get("stream") == "true"
+	enc := json.NewEncoder(w)
+	rc := http.NewResponseController(w)
+	if stream {
+		// Keep reading decks after the first result has been written.
+		rc.EnableFullDuplex()
+	}
+
+	var results []ValidationResult
+	entries := 0
+	for i := 0; dec.More(); i++ {
+		var deck Deck
+		status, msg := 0, ""
+		if err := dec.Decode(&deck); err != nil {
+			status, msg = http.StatusBadRequest, fmt.Sprintf("invalid deck JSON at index %d: %v", i, err)
+		} else if entries += deckEntries(&deck); maxCardEntries > 0 && entries > maxCardEntries {
+			status, msg = http.StatusRequestEntityTooLarge, tooManyCardEntries(entries).Error()
+		}
+		if status != 0 {
+			if stream && i > 0 {
+				// The status line is already sent; report the error in band.
+				enc.Encode(map[string]string{"error": msg})
+				return
+			}
+			http.Error(w, msg, status)
+			return
+		}
+
+		result := validateDeck(&deck, opts)
+		result.localize(locale)
+		if !stream {
+			results = append(results, result)
+			continue
+		}
+		if i == 0 {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("Content-Language", locale)
+		}
+		enc.Encode(result)
+		rc.Flush()
+	}
+
+	if stream {
+		return
+	}
+	if results == nil {
+		results = []ValidationResult{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", locale)
+	enc.Encode(results)
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// batchJobTTL is how long a submitted batch job waits to be streamed before
+// it is dropped.
+const batchJobTTL = 10 * time.Minute
+
+// maxBatchJobs caps the jobs waiting to be streamed, and maxBatchJobDecks
+// the decks in one job, since jobs hold their decks in memory until they
+// are streamed or expire.
+const (
+	maxBatchJobs     = 100
+	maxBatchJobDecks = 500
+)
+
+// errBatchJobsFull is returned when maxBatchJobs jobs are already waiting.
+var errBatchJobsFull = errors.New("too many batch jobs waiting, try again later")
+
+// batchJob is a batch validation submitted for streaming. The decks are
+// validated when the job is streamed, so a job does no work until a client
+// is listening.
+type batchJob struct {
+	decks   []Deck
+	opts    ValidateOptions
+	locale  string
+	created time.Time
+}
+
+// batchJobStore holds submitted jobs until they are streamed. Each job can
+// be streamed once. It is safe for concurrent use.
+type batchJobStore struct {
+	mu   sync.Mutex
+	jobs map[string]*batchJob
+}
+
+var batchJobs = &batchJobStore{jobs: map[string]*batchJob{}}
+
+// add stores job under a new random ID, failing with errBatchJobsFull when
+// maxBatchJobs unexpired jobs are already waiting.
+func (s *batchJobStore) add(job *batchJob) (string, error) {
+	var raw [16]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(raw[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purgeLocked()
+	if len(s.jobs) >= maxBatchJobs {
+		return "", errBatchJobsFull
+	}
+	s.jobs[id] = job
+	return id, nil
+}
+
+// purge drops jobs older than batchJobTTL.
+func (s *batchJobStore) purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purgeLocked()
+}
+
+func (s *batchJobStore) purgeLocked() {
+	for id, j := range s.jobs {
+		if time.Since(j.created) > batchJobTTL {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// purgeEvery purges expired jobs every interval, so the decks of jobs that
+// are never streamed are freed even when no new jobs arrive. It doesn't
+// return.
+func (s *batchJobStore) purgeEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.purge()
+	}
+}
+
+// take removes and returns the job with the given ID.
+func (s *batchJobStore) take(id string) (*batchJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	delete(s.jobs, id)
+	if ok && time.Since(job.created) > batchJobTTL {
+		return nil, false
+	}
+	return job, ok
+}
+
+type batchJobResponse struct {
+	JobID string `json:"jobId"`
+	Decks int    `json:"decks"`
+}
+
+// createBatchJobHandler accepts a JSON array of decks, with the same query
+// parameters as Validate Batch, and returns a job ID for
+// validateBatchStreamHandler.
+func createBatchJobHandler(w http.ResponseWriter, r *http.Request) {
+	var decks []Deck
+	if err := json.NewDecoder(r.Body).Decode(&decks); err != nil {
+		http.Error(w, fmt.Sprintf("request body must be a JSON array of decks: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(decks) > maxBatchJobDecks {
+		http.Error(w, fmt.Sprintf("a job may have at most %d decks", maxBatchJobDecks), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	job := &batchJob{
+		decks:   decks,
+		opts:    validateOptionsFromRequest(r),
+		locale:  negotiateLocale(r.Header.Get("Accept-Language")),
+		created: time.Now(),
+	}
+	id, err := batchJobs.add(job)
+	if errors.Is(err, errBatchJobsFull) {
+		w.Header().Set("Retry-After", retryAfter(time.Minute))
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	if err != nil {
+		http.Error(w, "could not create job", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(batchJobResponse{JobID: id, Decks: len(decks)})
+}
+
+// batchStreamResult is the data of a result event.
+type batchStreamResult struct {
+	Index  int              `json:"index"`
+	Result ValidationResult `json:"result"`
+}
+
+// validateBatchStreamHandler validates the decks of a job and sends each
+// result as a Server-Sent Events "result" event as soon as it is ready,
+// then a "done" event. The job runs under the request's context, so it
+// stops when the client disconnects.
+func validateBatchStreamHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	id := r.URL.Query().Get("job")
+	if id == "" {
+		http.Error(w, "job parameter required", http.StatusBadRequest)
+		return
+	}
+	job, ok := batchJobs.take(id)
+	if !ok {
+		http.Error(w, "unknown or expired job", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Language", job.locale)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx := r.Context()
+	for i := range job.decks {
+		if ctx.Err() != nil {
+			return
+		}
+		result := validateDeck(&job.decks[i], job.opts)
+		result.localize(job.locale)
+		writeEvent(w, "result", i, batchStreamResult{Index: i, Result: result})
+		flusher.Flush()
+	}
+	writeEvent(w, "done", len(job.decks), map[string]int{"count": len(job.decks)})
+	flusher.Flush()
+}
+
+// writeEvent writes one Server-Sent Event with a JSON data line.
+func writeEvent(w http.ResponseWriter, event string, id int, data any) {
+	payload, _ := json.Marshal(data)
+	fmt.Fprintf(w, "event: %s\nid: %d\ndata: %s\n\n", event, id, payload)
+}
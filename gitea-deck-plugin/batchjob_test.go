@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatchJobLifecycle(t *testing.T) {
+	saved := batchJobs
+	defer func() { batchJobs = saved }()
+	batchJobs = &batchJobStore{jobs: map[string]*batchJob{}}
+
+	body := `[{"game":"mtg","format":"modern","cards":[{"name":"Island","count":60}]},{"game":"mtg","format":"modern","cards":[]}]`
+	rec := httptest.NewRecorder()
+	createBatchJobHandler(rec, httptest.NewRequest(http.MethodPost, "/validate-batch-job", strings.NewReader(body)))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("create: status %d, want 202", rec.Code)
+	}
+	var job batchJobResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil || job.JobID == "" || job.Decks != 2 {
+		t.Fatalf("create: got %s", rec.Body.String())
+	}
+
+	tests := []struct {
+		name   string
+		job    string
+		status int
+		events []string
+	}{
+		{"stream", job.JobID, http.StatusOK, []string{"result", "result", "done"}},
+		{"stream again", job.JobID, http.StatusNotFound, nil},
+		{"unknown job", "feedface", http.StatusNotFound, nil},
+		{"no job", "", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		validateBatchStreamHandler(rec, httptest.NewRequest(http.MethodGet, "/validate-batch-stream?job="+tt.job, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.status)
+			continue
+		}
+		var events []string
+		for _, line := range strings.Split(rec.Body.String(), "\n") {
+			if event, ok := strings.CutPrefix(line, "event: "); ok {
+				events = append(events, event)
+			}
+		}
+		if fmt.Sprint(events) != fmt.Sprint(tt.events) {
+			t.Errorf("%s: events %v, want %v", tt.name, events, tt.events)
+		}
+	}
+}
+
+func TestBatchJobExpiry(t *testing.T) {
+	tests := []struct {
+		name  string
+		age   time.Duration
+		fresh bool
+	}{
+		{"new", 0, true},
+		{"almost expired", batchJobTTL - time.Minute, true},
+		{"expired", batchJobTTL + time.Second, false},
+	}
+	for _, tt := range tests {
+		store := &batchJobStore{jobs: map[string]*batchJob{}}
+		id, err := store.add(&batchJob{created: time.Now().Add(-tt.age)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		store.purge()
+		if _, kept := store.jobs[id]; kept != tt.fresh {
+			t.Errorf("%s: kept by purge = %v, want %v", tt.name, kept, tt.fresh)
+		}
+		store.jobs[id] = &batchJob{created: time.Now().Add(-tt.age)}
+		if _, ok := store.take(id); ok != tt.fresh {
+			t.Errorf("%s: take found it = %v, want %v", tt.name, ok, tt.fresh)
+		}
+		if _, ok := store.take(id); ok {
+			t.Errorf("%s: a job was taken twice", tt.name)
+		}
+	}
+}
+
+func TestBatchJobLimits(t *testing.T) {
+	saved := batchJobs
+	defer func() { batchJobs = saved }()
+
+	tests := []struct {
+		name       string
+		waiting    int           // jobs already in the store
+		age        time.Duration // age of the waiting jobs
+		decks      int
+		status     int
+		retryAfter bool
+	}{
+		{"room for a job", maxBatchJobs - 1, 0, 1, http.StatusAccepted, false},
+		{"store full", maxBatchJobs, 0, 1, http.StatusTooManyRequests, true},
+		{"full of expired jobs", maxBatchJobs, batchJobTTL + time.Second, 1, http.StatusAccepted, false},
+		{"too many decks", 0, 0, maxBatchJobDecks + 1, http.StatusRequestEntityTooLarge, false},
+		{"most decks", 0, 0, maxBatchJobDecks, http.StatusAccepted, false},
+	}
+	for _, tt := range tests {
+		batchJobs = &batchJobStore{jobs: map[string]*batchJob{}}
+		for i := 0; i < tt.waiting; i++ {
+			batchJobs.jobs[fmt.Sprint(i)] = &batchJob{created: time.Now().Add(-tt.age)}
+		}
+		decks := make([]Deck, tt.decks)
+		for i := range decks {
+			decks[i] = Deck{Game: "mtg", Cards: []DeckCard{{Name: "Island", Count: 1}}}
+		}
+		body, _ := json.Marshal(decks)
+		rec := httptest.NewRecorder()
+		createBatchJobHandler(rec, httptest.NewRequest(http.MethodPost, "/validate-batch-job", strings.NewReader(string(body))))
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, rec.Code, tt.status, strings.TrimSpace(rec.Body.String()))
+		}
+		if got := rec.Header().Get("Retry-After") != ""; got != tt.retryAfter {
+			t.Errorf("%s: Retry-After set = %v, want %v", tt.name, got, tt.retryAfter)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// mtgFormats are the constructed MTG formats validateDeck has built-in rules
+// for. Formats defined in rules.Formats are ranked alongside them; pools are
+// left out since almost any list is a legal pool.
+var mtgFormats = []string{"commander", "standard", "modern", "legacy", "canlander", "oathbreaker"}
+
+// isConstructedFormat reports whether format is one of mtgFormats or is
+// defined in rules.Formats.
+func isConstructedFormat(format string) bool {
+	if _, ok := rules.Formats[format]; ok {
+		return true
+	}
+	for _, f := range mtgFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatRank is how a deck fares in one format.
+type FormatRank struct {
+	Format   string `json:"format"`
+	Name     string `json:"name"`
+	Valid    bool   `json:"valid"`
+	Errors   int    `json:"errors"`
+	Warnings int    `json:"warnings"`
+}
+
+// rankFormats validates the deck in every known MTG format and orders the
+// formats by error count, then warning count, then name.
+func rankFormats(deck *Deck) []FormatRank {
+	formats := append([]string{}, mtgFormats...)
+	for format := range rules.Formats {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+
+	ranks := []FormatRank{}
+	for _, format := range formats {
+		d := *deck
+		d.Format = format
+		result := validateDeck(&d, ValidateOptions{})
+		rank := FormatRank{Format: format, Name: formatDisplayName(format), Valid: result.Valid}
+		for _, issue := range result.Issues {
+			switch issue.Severity {
+			case SeverityError:
+				rank.Errors++
+			case SeverityWarning:
+				rank.Warnings++
+			}
+		}
+		ranks = append(ranks, rank)
+	}
+	sort.SliceStable(ranks, func(i, j int) bool {
+		if ranks[i].Errors != ranks[j].Errors {
+			return ranks[i].Errors < ranks[j].Errors
+		}
+		return ranks[i].Warnings < ranks[j].Warnings
+	})
+	return ranks
+}
+
+func bestFormatHandler(w http.ResponseWriter, r *http.Request) {
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if game := strings.ToLower(r.URL.Query().Get("game")); game != "" {
+		deck.Game = game
+	}
+	if deck.Game != "mtg" {
+		http.Error(w, "best-format only supports game mtg", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rankFormats(deck))
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// CommanderBracket is one bracket of the Commander bracket system.
+// MaxGameChangers is how many cards from the game changers list the bracket
+// allows, or -1 for any number; Banned lists cards not allowed in the
+// bracket, such as mass land denial. MaxTutors and MaxFastMana limit the
+// powerCards tutors and fast mana, -1 for any number; they are guidance
+// used only by bracket detection.
+type CommanderBracket struct {
+	Name            string   `json:"name"`
+	MaxGameChangers int      `json:"maxGameChangers"`
+	Banned          []string `json:"banned,omitempty"`
+	MaxTutors       int      `json:"maxTutors"`
+	MaxFastMana     int      `json:"maxFastMana"`
+}
+
+// massLandDenial is kept out of brackets 1 to 3.
+var massLandDenial = []string{
+	"Armageddon", "Ravages of War", "Catastrophe", "Jokulhaups", "Obliterate",
+	"Decree of Annihilation", "Sunder", "Ruination", "Boil", "Destructive Flow",
+	"Blood Moon", "Back to Basics", "Winter Orb", "Static Orb", "Hokori, Dust Drinker",
+}
+
+func defaultCommanderBrackets() map[int]CommanderBracket {
+	return map[int]CommanderBracket{
+		1: {Name: "Exhibition", MaxGameChangers: 0, Banned: massLandDenial, MaxTutors: 1, MaxFastMana: 1},
+		2: {Name: "Core", MaxGameChangers: 0, Banned: massLandDenial, MaxTutors: 2, MaxFastMana: 1},
+		3: {Name: "Upgraded", MaxGameChangers: 3, Banned: massLandDenial, MaxTutors: 4, MaxFastMana: 3},
+		4: {Name: "Optimized", MaxGameChangers: -1, MaxTutors: -1, MaxFastMana: -1},
+		5: {Name: "cEDH", MaxGameChangers: -1, MaxTutors: -1, MaxFastMana: -1},
+	}
+}
+
+func defaultGameChangers() []string {
+	return []string{
+		"Ad Nauseam", "Ancient Tomb", "Aura Shards", "Bolas's Citadel",
+		"Chrome Mox", "Coalition Victory", "Consecrated Sphinx", "Crop Rotation",
+		"Cyclonic Rift", "Demonic Tutor", "Drannith Magistrate", "Enlightened Tutor",
+		"Field of the Dead", "Fierce Guardianship", "Force of Will", "Gaea's Cradle",
+		"Gamble", "Gifts Ungiven", "Glacial Chasm", "Grand Arbiter Augustin IV",
+		"Grim Monolith", "Humility", "Imperial Seal", "Intuition", "Jeska's Will",
+		"Lion's Eye Diamond", "Mana Vault", "Mishra's Workshop", "Mox Diamond",
+		"Mystical Tutor", "Narset, Parter of Veils", "Natural Order", "Necropotence",
+		"Notion Thief", "Opposition Agent", "Orcish Bowmasters", "Panoptic Mirror",
+		"Rhystic Study", "Seedborn Muse", "Serra's Sanctum", "Smothering Tithe",
+		"Survival of the Fittest", "Teferi's Protection", "Tergrid, God of Fright",
+		"Thassa's Oracle", "The One Ring", "The Tabernacle at Pendrell Vale",
+		"Underworld Breach", "Vampiric Tutor", "Worldly Tutor",
+	}
+}
+
+// checkBracket validates a Commander deck against the restrictions of the
+// bracket declared in Metadata.Bracket. Decks without a bracket skip the
+// check.
+func checkBracket(deck *Deck, result *ValidationResult) {
+	level := deck.Metadata.Bracket
+	if level == 0 {
+		return
+	}
+	bracket, ok := rules.CommanderBrackets[level]
+	if !ok {
+		result.addError(CodeUnknownBracket, "", level)
+		return
+	}
+
+	nameSet := func(names []string) map[string]bool {
+		set := map[string]bool{}
+		for _, name := range names {
+			set[normalizeName(deck.Game, name)] = true
+		}
+		return set
+	}
+	banned := nameSet(bracket.Banned)
+	changers := nameSet(rules.GameChangers)
+
+	seen := map[string]bool{}
+	var found []string
+	for _, card := range append(commandersOf(deck), deck.Cards...) {
+		key := cardKey(deck.Game, card)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if banned[key] {
+			result.addError(CodeBracketCardBanned, displayName(card), displayName(card), bracketLabel(level, bracket))
+		}
+		if changers[key] {
+			found = append(found, displayName(card))
+		}
+	}
+	if bracket.MaxGameChangers >= 0 && len(found) > bracket.MaxGameChangers {
+		result.addError(CodeGameChangerLimit, "", len(found), bracketLabel(level, bracket), bracket.MaxGameChangers, strings.Join(found, ", "))
+	}
+}
+
+func bracketLabel(level int, bracket CommanderBracket) string {
+	if bracket.Name == "" {
+		return fmt.Sprint(level)
+	}
+	return fmt.Sprintf("%d (%s)", level, bracket.Name)
+}
+
+// bracketProfile is what bracket detection found in a deck. Banned maps
+// each bracket level to the deck's cards on that bracket's banned list.
+type bracketProfile struct {
+	GameChangers []string
+	Tutors       []string
+	FastMana     []string
+	Banned       map[int][]string
+}
+
+// profileBracket lists the deck's game changers, tutors, fast mana and
+// bracket-banned cards, counting each card once.
+func profileBracket(deck *Deck) bracketProfile {
+	nameSet := func(names []string) map[string]bool {
+		set := map[string]bool{}
+		for _, name := range names {
+			set[normalizeName(deck.Game, name)] = true
+		}
+		return set
+	}
+	changers := nameSet(rules.GameChangers)
+	tutors := nameSet(rules.PowerCards.Tutors)
+	fastMana := nameSet(rules.PowerCards.FastMana)
+	banned := map[int]map[string]bool{}
+	for level, bracket := range rules.CommanderBrackets {
+		banned[level] = nameSet(bracket.Banned)
+	}
+
+	profile := bracketProfile{GameChangers: []string{}, Tutors: []string{}, FastMana: []string{}, Banned: map[int][]string{}}
+	seen := map[string]bool{}
+	for _, card := range append(commandersOf(deck), deck.Cards...) {
+		key := cardKey(deck.Game, card)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		name := displayName(card)
+		if changers[key] {
+			profile.GameChangers = append(profile.GameChangers, name)
+		}
+		if tutors[key] {
+			profile.Tutors = append(profile.Tutors, name)
+		}
+		if fastMana[key] {
+			profile.FastMana = append(profile.FastMana, name)
+		}
+		for level, set := range banned {
+			if set[key] {
+				profile.Banned[level] = append(profile.Banned[level], name)
+			}
+		}
+	}
+	return profile
+}
+
+// detectBracket returns the lowest bracket level whose limits the deck
+// meets, or 0 if it meets none.
+func detectBracket(deck *Deck) int {
+	return lowestBracket(profileBracket(deck))
+}
+
+func lowestBracket(profile bracketProfile) int {
+	var levels []int
+	for level := range rules.CommanderBrackets {
+		levels = append(levels, level)
+	}
+	sort.Ints(levels)
+
+	within := func(n, max int) bool { return max < 0 || n <= max }
+	for _, level := range levels {
+		bracket := rules.CommanderBrackets[level]
+		if len(profile.Banned[level]) == 0 &&
+			within(len(profile.GameChangers), bracket.MaxGameChangers) &&
+			within(len(profile.Tutors), bracket.MaxTutors) &&
+			within(len(profile.FastMana), bracket.MaxFastMana) {
+			return level
+		}
+	}
+	return 0
+}
+
+type detectBracketResponse struct {
+	Bracket      int      `json:"bracket"`
+	Name         string   `json:"name,omitempty"`
+	GameChangers []string `json:"gameChangers"`
+	Tutors       []string `json:"tutors"`
+	FastMana     []string `json:"fastMana"`
+	// Banned lists, per bracket level, the deck's cards that bracket bans.
+	Banned map[int][]string `json:"banned"`
+}
+
+func detectBracketHandler(w http.ResponseWriter, r *http.Request) {
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	profile := profileBracket(deck)
+	level := lowestBracket(profile)
+	resp := detectBracketResponse{
+		Bracket:      level,
+		Name:         rules.CommanderBrackets[level].Name,
+		GameChangers: profile.GameChangers,
+		Tutors:       profile.Tutors,
+		FastMana:     profile.FastMana,
+		Banned:       profile.Banned,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
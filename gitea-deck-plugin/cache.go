@@ -0,0 +1,151 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// rulesVersion identifies the active rule set. It is part of every cache
+// key, so loading different rules never serves verdicts made under the old
+// ones.
+var rulesVersion string
+
+func computeRulesVersion(r *Rules) string {
+	data, _ := json.Marshal(r)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// validationCache is an LRU cache of validation results with a TTL. Entries
+// older than the TTL are dropped when read. It is safe for concurrent use.
+type validationCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+
+	hits, misses, evictions, expirations int
+}
+
+type cacheEntry struct {
+	key     string
+	result  ValidationResult
+	expires time.Time
+}
+
+// validationCacheStats is the JSON form of the cache counters.
+type validationCacheStats struct {
+	Size        int    `json:"size"`
+	Capacity    int    `json:"capacity"`
+	TTL         string `json:"ttl"`
+	Hits        int    `json:"hits"`
+	Misses      int    `json:"misses"`
+	Evictions   int    `json:"evictions"`
+	Expirations int    `json:"expirations"`
+}
+
+// resultCache caches validateDeckHandler results. A size of 0 disables it.
+var resultCache = newValidationCache(0, 0)
+
+func newValidationCache(size int, ttl time.Duration) *validationCache {
+	return &validationCache{size: size, ttl: ttl, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// cacheKey hashes everything a verdict depends on: the rules, the request
+// options and the deck itself.
+func cacheKey(deck *Deck, opts ValidateOptions) string {
+	data, _ := json.Marshal(struct {
+		Rules string
+		Opts  ValidateOptions
+		Deck  *Deck
+	}{rulesVersion, opts, deck})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns a copy of the cached result for key, if there is a fresh one.
+func (c *validationCache) get(key string) (ValidationResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size <= 0 {
+		return ValidationResult{}, false
+	}
+	el, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return ValidationResult{}, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if c.ttl > 0 && time.Now().After(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		c.expirations++
+		c.misses++
+		return ValidationResult{}, false
+	}
+	c.order.MoveToFront(el)
+	c.hits++
+
+	return copyResult(entry.result), true
+}
+
+// copyResult copies result's slices, so neither the cache nor its callers
+// see the other's changes: localize rewrites issue messages in place, and
+// the handler appends warnings to a cached verdict.
+func copyResult(result ValidationResult) ValidationResult {
+	result.Errors = append([]string{}, result.Errors...)
+	result.Warnings = append([]string{}, result.Warnings...)
+	result.Issues = append([]Issue{}, result.Issues...)
+	return result
+}
+
+// put stores a result, evicting the least recently used entry when full.
+func (c *validationCache) put(key string, result ValidationResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size <= 0 {
+		return
+	}
+	entry := &cacheEntry{key: key, result: copyResult(result), expires: time.Now().Add(c.ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+		c.evictions++
+	}
+}
+
+func (c *validationCache) stats() validationCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ttl := "none"
+	if c.ttl > 0 {
+		ttl = c.ttl.String()
+	}
+	return validationCacheStats{
+		Size:        c.order.Len(),
+		Capacity:    c.size,
+		TTL:         ttl,
+		Hits:        c.hits,
+		Misses:      c.misses,
+		Evictions:   c.evictions,
+		Expirations: c.expirations,
+	}
+}
+
+func cacheStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resultCache.stats())
+}
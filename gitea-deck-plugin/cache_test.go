@@ -0,0 +1,150 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestValidationCacheEviction(t *testing.T) {
+	tests := []struct {
+		name      string
+		size      int
+		ops       []string // "+k" puts k, "?k" reads it
+		want      []string // keys still cached
+		gone      []string // keys evicted
+		evictions int
+	}{
+		{"under capacity", 3, []string{"+a", "+b"}, []string{"a", "b"}, nil, 0},
+		{"evicts the oldest", 2, []string{"+a", "+b", "+c"}, []string{"b", "c"}, []string{"a"}, 1},
+		{"a read keeps an entry", 2, []string{"+a", "+b", "?a", "+c"}, []string{"a", "c"}, []string{"b"}, 1},
+		{"re-put doesn't grow", 2, []string{"+a", "+a", "+b"}, []string{"a", "b"}, nil, 0},
+		{"disabled", 0, []string{"+a"}, nil, []string{"a"}, 0},
+	}
+	for _, tt := range tests {
+		c := newValidationCache(tt.size, 0)
+		for _, op := range tt.ops {
+			if op[0] == '+' {
+				c.put(op[1:], ValidationResult{Valid: true})
+			} else {
+				c.get(op[1:])
+			}
+		}
+		// Check evictions before the reads below move entries around.
+		if got := c.stats().Evictions; got != tt.evictions {
+			t.Errorf("%s: %d evictions, want %d", tt.name, got, tt.evictions)
+		}
+		for _, key := range tt.want {
+			if _, ok := c.get(key); !ok {
+				t.Errorf("%s: %q was evicted", tt.name, key)
+			}
+		}
+		for _, key := range tt.gone {
+			if _, ok := c.get(key); ok {
+				t.Errorf("%s: %q is still cached", tt.name, key)
+			}
+		}
+	}
+}
+
+func TestValidationCacheTTL(t *testing.T) {
+	tests := []struct {
+		name        string
+		ttl         time.Duration
+		age         time.Duration
+		want        bool
+		expirations int
+	}{
+		{"fresh", time.Minute, 0, true, 0},
+		{"expired", time.Minute, 2 * time.Minute, false, 1},
+		{"no TTL", 0, 0, true, 0},
+	}
+	for _, tt := range tests {
+		c := newValidationCache(10, tt.ttl)
+		c.put("k", ValidationResult{Valid: true})
+		if tt.age > 0 {
+			c.entries["k"].Value.(*cacheEntry).expires = time.Now().Add(tt.ttl - tt.age)
+		}
+		if _, ok := c.get("k"); ok != tt.want {
+			t.Errorf("%s: cached = %v, want %v", tt.name, ok, tt.want)
+		}
+		if got := c.stats().Expirations; got != tt.expirations {
+			t.Errorf("%s: %d expirations, want %d", tt.name, got, tt.expirations)
+		}
+	}
+}
+
+func TestValidationCacheCopies(t *testing.T) {
+	c := newValidationCache(10, 0)
+	var result ValidationResult
+	// Three warnings leave the slices room to grow in place, so callers
+	// that share them would overwrite each other's appends.
+	result.addWarning(CodeMissingDeckName, "")
+	result.addWarning(CodeNoWinCondition, "")
+	result.addWarning(CodeMissingDeckName, "")
+	c.put("k", result)
+	result.addWarning(CodeSideboardMerged, "")
+
+	first, _ := c.get("k")
+	first.addWarning(CodeSideboardMerged, "")
+	first.Issues[0].Message = "changed"
+	second, _ := c.get("k")
+	second.addWarning(CodeUnknownFormat, "", "x")
+
+	if first.Warnings[3] != first.Issues[3].Message || first.Issues[3].Code != CodeSideboardMerged {
+		t.Errorf("another caller overwrote a warning: %q", first.Warnings[3])
+	}
+	again, _ := c.get("k")
+	if len(again.Warnings) != 3 || len(again.Issues) != 3 {
+		t.Errorf("cached result has %d warnings and %d issues, want 3 of each", len(again.Warnings), len(again.Issues))
+	}
+	if again.Issues[0].Message == "changed" {
+		t.Error("a caller's change to an issue reached the cache")
+	}
+}
+
+// TestValidateDeckCacheHitsRace sends the same deck with a sideboard
+// parameter from many goroutines, so cache hits add the merge warning
+// concurrently. The deck has three warnings, leaving the slices room to
+// grow in place. Run with -race.
+func TestValidateDeckCacheHitsRace(t *testing.T) {
+	saved := resultCache
+	defer func() { resultCache = saved }()
+	resultCache = newValidationCache(10, 0)
+
+	query := url.Values{
+		"content":   {`{"game":"mtg","format":"modern","cards":[{"name":"Island","count":59},{"name":"Opt","count":1,"type":"Instant"}],"sideboard":[{"name":"Negate","count":2}]}`},
+		"sideboard": {`[{"name":"Disdainful Stroke","count":2}]`},
+	}.Encode()
+	// Fill the cache first, so every request below is a hit.
+	validateDeckHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/validate?"+query, nil))
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	bodies := make([]string, 32)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			rec := httptest.NewRecorder()
+			validateDeckHandler(rec, httptest.NewRequest(http.MethodGet, "/validate?"+query, nil))
+			bodies[i] = rec.Body.String()
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for i, body := range bodies {
+		if n := strings.Count(body, `"code":"`+CodeSideboardMerged+`"`); n != 1 {
+			t.Errorf("response %d has %d %s issues, want 1: %s", i, n, CodeSideboardMerged, body)
+		}
+	}
+	if hits := resultCache.stats().Hits; hits != len(bodies) {
+		t.Errorf("%d cache hits, want %d", hits, len(bodies))
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// timestampLayouts are the date formats canonicalize recognizes in deck
+// metadata, most specific first.
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	time.RFC1123Z,
+	time.RFC1123,
+	"2006-01-02",
+}
+
+// canonicalize returns a copy of deck in a deterministic form for clean
+// diffs: duplicate entries in each zone are merged, entries are sorted by
+// name, card names take the card database's spelling when one is loaded,
+// and metadata timestamps are rewritten as RFC 3339. Timestamps that can't
+// be parsed are left alone.
+func canonicalize(deck *Deck) *Deck {
+	out := *deck
+	out.Cards = canonicalZone(deck.Game, deck.Cards)
+	out.Sideboard = canonicalZone(deck.Game, deck.Sideboard)
+	out.Maybeboard = canonicalZone(deck.Game, deck.Maybeboard)
+	out.Commanders = canonicalZone(deck.Game, deck.Commanders)
+	out.Battlefields = canonicalZone(deck.Game, deck.Battlefields)
+	out.Runes = canonicalZone(deck.Game, deck.Runes)
+	for _, card := range []**DeckCard{&out.Commander, &out.Companion, &out.Oathbreaker, &out.SignatureSpell, &out.Legend, &out.Battlefield} {
+		if *card != nil {
+			c := canonicalCard(deck.Game, **card)
+			*card = &c
+		}
+	}
+	out.Metadata.Created = canonicalTimestamp(deck.Metadata.Created)
+	out.Metadata.Updated = canonicalTimestamp(deck.Metadata.Updated)
+	return &out
+}
+
+// canonicalZone merges entries for the same card (same ID and normalized
+// name) and sorts the result by name, then ID.
+func canonicalZone(game string, cards []DeckCard) []DeckCard {
+	if cards == nil {
+		return nil
+	}
+	merged := []DeckCard{}
+	index := map[string]int{}
+	for _, card := range cards {
+		card = canonicalCard(game, card)
+		key := card.ID + "\x00" + cardKey(game, card)
+		if i, ok := index[key]; ok {
+			merged[i].Count += card.Count
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, card)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		ki, kj := cardKey(game, merged[i]), cardKey(game, merged[j])
+		if ki != kj {
+			return ki < kj
+		}
+		return merged[i].ID < merged[j].ID
+	})
+	return merged
+}
+
+// canonicalCard replaces the card's name with the card database's spelling,
+// if a database is loaded and knows the card.
+func canonicalCard(game string, card DeckCard) DeckCard {
+	if cardDB == nil || card.Name == "" {
+		return card
+	}
+	if known, ok := cardDB.LookupName(game, card.Name); ok {
+		card.Name = known.Name
+	}
+	return card
+}
+
+func canonicalTimestamp(s string) string {
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	return s
+}
+
+func canonicalizeHandler(w http.ResponseWriter, r *http.Request) {
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := json.MarshalIndent(canonicalize(deck), "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(data, '\n'))
+}
@@ -0,0 +1,418 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Card is a card database entry.
+type Card struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Game     string   `json:"game"`
+	Set      string   `json:"set,omitempty"`
+	Type     string   `json:"type,omitempty"`
+	Rarity   string   `json:"rarity,omitempty"`
+	CMC      float64  `json:"cmc,omitempty"`
+	Colors   []string `json:"colors,omitempty"`
+	ImageURL string   `json:"image_url,omitempty"`
+}
+
+// CardDB looks up cards for a game.
+type CardDB interface {
+	// Search returns up to limit cards in game whose name contains query,
+	// ordered by searchKey, starting strictly after the key after. An empty
+	// after starts from the beginning. more reports whether further matches
+	// exist past the returned page.
+	Search(game, query, after string, limit int) (cards []Card, more bool)
+
+	// Lookup finds a card in game by ID.
+	Lookup(game, id string) (Card, bool)
+
+	// LookupName finds a card in game by name, compared after
+	// normalization.
+	LookupName(game, name string) (Card, bool)
+
+	// Games lists the games the database has cards for, in sorted order.
+	Games() []string
+}
+
+// cardDB is the loaded card database, or nil when none was configured.
+var cardDB CardDB
+
+// searchKey is the stable sort key used for search ordering and cursors.
+func searchKey(c Card) string {
+	return normalizeName(c.Game, c.Name) + "\x00" + c.ID
+}
+
+// memoryCardDB is a CardDB backed by card lists held in memory, sorted by
+// searchKey per game.
+type memoryCardDB struct {
+	games  map[string][]Card
+	byID   map[string]map[string]Card
+	byName map[string]map[string]Card
+}
+
+// loadCardDB reads one or more JSON card files, each holding an array of
+// Card objects. Game names are lower-cased so "Riftbound" and "riftbound"
+// share a list.
+func loadCardDB(paths []string) (*memoryCardDB, error) {
+	db := &memoryCardDB{
+		games:  map[string][]Card{},
+		byID:   map[string]map[string]Card{},
+		byName: map[string]map[string]Card{},
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading card file: %w", err)
+		}
+		var cards []Card
+		if err := json.Unmarshal(data, &cards); err != nil {
+			return nil, fmt.Errorf("parsing card file %s: %w", path, err)
+		}
+		for _, c := range cards {
+			c.Game = strings.ToLower(c.Game)
+			db.games[c.Game] = append(db.games[c.Game], c)
+			if db.byID[c.Game] == nil {
+				db.byID[c.Game] = map[string]Card{}
+				db.byName[c.Game] = map[string]Card{}
+			}
+			db.byID[c.Game][c.ID] = c
+			if _, dup := db.byName[c.Game][normalizeName(c.Game, c.Name)]; !dup {
+				db.byName[c.Game][normalizeName(c.Game, c.Name)] = c
+			}
+		}
+	}
+	for game, cards := range db.games {
+		sort.Slice(cards, func(i, j int) bool { return searchKey(cards[i]) < searchKey(cards[j]) })
+		db.games[game] = cards
+	}
+	return db, nil
+}
+
+func (db *memoryCardDB) Search(game, query, after string, limit int) ([]Card, bool) {
+	cards := db.games[strings.ToLower(game)]
+	q := normalizeName(game, query)
+
+	start := sort.Search(len(cards), func(i int) bool { return searchKey(cards[i]) > after })
+	var page []Card
+	for _, c := range cards[start:] {
+		if !strings.Contains(normalizeName(c.Game, c.Name), q) {
+			continue
+		}
+		if len(page) == limit {
+			return page, true
+		}
+		page = append(page, c)
+	}
+	return page, false
+}
+
+func (db *memoryCardDB) Lookup(game, id string) (Card, bool) {
+	c, ok := db.byID[strings.ToLower(game)][id]
+	return c, ok
+}
+
+func (db *memoryCardDB) LookupName(game, name string) (Card, bool) {
+	c, ok := db.byName[strings.ToLower(game)][normalizeName(game, name)]
+	return c, ok
+}
+
+func (db *memoryCardDB) Games() []string {
+	games := make([]string, 0, len(db.games))
+	for game := range db.games {
+		games = append(games, game)
+	}
+	sort.Strings(games)
+	return games
+}
+
+// cardBelongsToGame reports whether the card database has a card with the
+// given ID in game.
+func cardBelongsToGame(game, id string) bool {
+	_, ok := cardDB.Lookup(game, id)
+	return ok
+}
+
+// foreignGame returns the other game in the card database that a card not
+// found in game resolves in, by ID when the entry has one and by name
+// otherwise, or "" if there is none.
+func foreignGame(game string, card DeckCard) string {
+	for _, other := range cardDB.Games() {
+		if other == strings.ToLower(game) {
+			continue
+		}
+		if card.ID != "" && cardBelongsToGame(other, card.ID) {
+			return other
+		}
+		if card.ID == "" && card.Name != "" {
+			if _, ok := cardDB.LookupName(other, card.Name); ok {
+				return other
+			}
+		}
+	}
+	return ""
+}
+
+// checkKnownCards warns about cards the card database doesn't know, by ID
+// when the entry has one and by name otherwise, and errors for cards it
+// knows only under another game, suggesting the closest known name for the
+// first maxSuggestionsPerDeck unknown names. It does nothing when no
+// database is configured. Custom cards are skipped when opts.AllowCustom is
+// set.
+func checkKnownCards(deck *Deck, opts ValidateOptions, result *ValidationResult) {
+	if cardDB == nil {
+		return
+	}
+	suggestions := 0
+	for _, card := range playedCards(deck) {
+		if card.Custom && opts.AllowCustom {
+			continue
+		}
+		switch {
+		case card.ID != "":
+			if !cardBelongsToGame(deck.Game, card.ID) {
+				if other := foreignGame(deck.Game, card); other != "" {
+					result.addError(CodeWrongGameCard, displayName(card), displayName(card), other, deck.Game)
+				} else {
+					result.addWarning(CodeUnknownCardID, displayName(card), card.ID)
+				}
+			}
+		case card.Name != "":
+			if _, ok := cardDB.LookupName(deck.Game, card.Name); ok {
+				break
+			}
+			if other := foreignGame(deck.Game, card); other != "" {
+				result.addError(CodeWrongGameCard, card.Name, card.Name, other, deck.Game)
+				break
+			}
+			suggestion := ""
+			if suggestions < maxSuggestionsPerDeck {
+				suggestions++
+				suggestion = suggestClosest(deck.Game, card.Name)
+			}
+			if suggestion != "" {
+				result.addWarning(CodeUnknownCardSuggest, card.Name, card.Name, suggestion)
+			} else {
+				result.addWarning(CodeUnknownCardName, card.Name, card.Name)
+			}
+		}
+	}
+}
+
+// suggestClosest returns the card in game whose normalized name is the
+// fewest edits from name, or "" when none is close enough to be a likely
+// typo. The allowed distance grows with the name's length, up to
+// maxSuggestDistance. Ties go to the first card in search order. Only
+// names whose length is within the allowed distance are compared, since
+// the rest can't be close enough.
+func suggestClosest(game, name string) string {
+	if cardDB == nil {
+		return ""
+	}
+	target := []rune(normalizeName(game, name))
+	limit := len(target) / 4
+	if limit < 1 {
+		limit = 1
+	}
+	if limit > maxSuggestDistance {
+		limit = maxSuggestDistance
+	}
+
+	index := suggestIndexes.get(cardDB, game)
+	best, bestDistance, bestPos := "", limit+1, 0
+	for n := len(target) - limit; n <= len(target)+limit; n++ {
+		for _, entry := range index[n] {
+			d := editDistance(target, entry.runes)
+			if d < bestDistance || d == bestDistance && entry.pos < bestPos {
+				best, bestDistance, bestPos = entry.name, d, entry.pos
+			}
+		}
+	}
+	return best
+}
+
+// suggestEntry is a card name prepared for suggestClosest: its normalized
+// runes and its position in search order.
+type suggestEntry struct {
+	name  string
+	runes []rune
+	pos   int
+}
+
+// suggestIndexCache holds, per game, the card database's names keyed by
+// normalized length. Each game is built on first use and kept until the
+// database changes. It is safe for concurrent use.
+type suggestIndexCache struct {
+	mu    sync.Mutex
+	db    CardDB
+	games map[string]map[int][]suggestEntry
+}
+
+var suggestIndexes = &suggestIndexCache{}
+
+func (c *suggestIndexCache) get(db CardDB, game string) map[int][]suggestEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.db != db || c.games == nil {
+		c.db, c.games = db, map[string]map[int][]suggestEntry{}
+	}
+	if index, ok := c.games[game]; ok {
+		return index
+	}
+
+	index := map[int][]suggestEntry{}
+	pos, after := 0, ""
+	for {
+		cards, more := db.Search(game, "", after, 500)
+		for _, card := range cards {
+			runes := []rune(normalizeName(game, card.Name))
+			index[len(runes)] = append(index[len(runes)], suggestEntry{name: card.Name, runes: runes, pos: pos})
+			pos++
+		}
+		if !more || len(cards) == 0 {
+			break
+		}
+		after = searchKey(cards[len(cards)-1])
+	}
+	c.games[game] = index
+	return index
+}
+
+// maxSuggestionsPerDeck caps the unknown names checkKnownCards looks up
+// suggestions for in one deck; the rest get a plain UNKNOWN_CARD_NAME.
+const maxSuggestionsPerDeck = 20
+
+// maxSuggestDistance is the most edits suggestClosest allows between a name
+// and its suggestion.
+const maxSuggestDistance = 3
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// requireIDs makes name-only cards an error in constructed formats.
+var requireIDs bool
+
+// checkCardIDs errors for every card without an ID when -require-ids is set
+// and the deck is in a constructed format, so only fully-resolved decklists
+// pass. Pools and formats the validator doesn't know are treated as casual. Custom cards are skipped when opts.AllowCustom is set.
+func checkCardIDs(deck *Deck, opts ValidateOptions, result *ValidationResult) {
+	if !requireIDs || deck.Game != "mtg" || !isConstructedFormat(deck.Format) {
+		return
+	}
+	for _, card := range playedCards(deck) {
+		if card.ID != "" || (card.Custom && opts.AllowCustom) {
+			continue
+		}
+		result.addError(CodeMissingCardID, card.Name, card.Name)
+	}
+}
+
+// searchCursor is the decoded form of the opaque cursor token. It records
+// the query it was issued for so a cursor can't be replayed against a
+// different search.
+type searchCursor struct {
+	Game  string `json:"g"`
+	Query string `json:"q"`
+	After string `json:"a"`
+}
+
+func encodeCursor(c searchCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (searchCursor, error) {
+	var c searchCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, errors.New("malformed cursor")
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, errors.New("malformed cursor")
+	}
+	return c, nil
+}
+
+type searchResponse struct {
+	Cards []Card `json:"cards"`
+	Next  string `json:"next,omitempty"`
+}
+
+func searchCardsHandler(w http.ResponseWriter, r *http.Request) {
+	if cardDB == nil {
+		http.Error(w, "card database not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	q := r.URL.Query()
+	game := strings.ToLower(q.Get("game"))
+	query := q.Get("q")
+	if game == "" {
+		http.Error(w, "game parameter required", http.StatusBadRequest)
+		return
+	}
+
+	limit := 50
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 200 {
+			http.Error(w, "limit must be between 1 and 200", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	after := ""
+	if token := q.Get("cursor"); token != "" {
+		cursor, err := decodeCursor(token)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if cursor.Game != game || cursor.Query != query {
+			http.Error(w, "cursor does not match this search", http.StatusBadRequest)
+			return
+		}
+		after = cursor.After
+	}
+
+	cards, more := cardDB.Search(game, query, after, limit)
+	resp := searchResponse{Cards: cards}
+	if resp.Cards == nil {
+		resp.Cards = []Card{}
+	}
+	if more {
+		resp.Next = encodeCursor(searchCursor{Game: game, Query: query, After: searchKey(cards[len(cards)-1])})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
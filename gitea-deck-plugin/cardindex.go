@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// CardIndexEntry records how widely a card is used across a set of decks.
+type CardIndexEntry struct {
+	Game   string `json:"game"`
+	Name   string `json:"name"`
+	Decks  int    `json:"decks"`
+	Copies int    `json:"copies"`
+}
+
+// indexCards lists every distinct card across the decks with the number of
+// decks it appears in and its total copies, most widely played first. Names
+// are grouped per game after normalization.
+func indexCards(decks []Deck) []CardIndexEntry {
+	entries := map[string]*CardIndexEntry{}
+	for i := range decks {
+		deck := &decks[i]
+		inDeck := map[string]bool{}
+		for _, card := range playedCards(deck) {
+			key := deck.Game + "\x00" + cardKey(deck.Game, card)
+			entry, ok := entries[key]
+			if !ok {
+				entry = &CardIndexEntry{Game: deck.Game, Name: displayName(card)}
+				entries[key] = entry
+			}
+			entry.Copies += card.Count
+			if !inDeck[key] {
+				inDeck[key] = true
+				entry.Decks++
+			}
+		}
+	}
+
+	index := make([]CardIndexEntry, 0, len(entries))
+	for _, entry := range entries {
+		index = append(index, *entry)
+	}
+	sort.Slice(index, func(i, j int) bool {
+		a, b := index[i], index[j]
+		if a.Decks != b.Decks {
+			return a.Decks > b.Decks
+		}
+		if a.Copies != b.Copies {
+			return a.Copies > b.Copies
+		}
+		if a.Game != b.Game {
+			return a.Game < b.Game
+		}
+		return a.Name < b.Name
+	})
+	return index
+}
+
+func cardIndexHandler(w http.ResponseWriter, r *http.Request) {
+	var decks []Deck
+	if err := json.NewDecoder(r.Body).Decode(&decks); err != nil {
+		http.Error(w, fmt.Sprintf("invalid deck list JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(indexCards(decks))
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxBodyBytes is the largest request body accepted, in bytes. Bigger
+// bodies are rejected with 413.
+var maxBodyBytes int64 = 10 << 20
+
+// maxCardEntries is the number of card entries a request may declare
+// across all its decks and zones. 0 means unlimited.
+var maxCardEntries = 5000
+
+// errTooManyCardEntries is returned when a request declares more than
+// maxCardEntries card entries. Handlers report it as 413.
+var errTooManyCardEntries = errors.New("too many card entries")
+
+func tooManyCardEntries(n int) error {
+	return fmt.Errorf("%w: request has %d, more than the maximum of %d", errTooManyCardEntries, n, maxCardEntries)
+}
+
+// limitedBody records whether the request body went over maxBodyBytes.
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// bodyLimitWriter turns the 400 a handler sends for a body it couldn't
+// read into a 413 when the body was cut off at maxBodyBytes.
+type bodyLimitWriter struct {
+	http.ResponseWriter
+	body *limitedBody
+}
+
+func (w *bodyLimitWriter) WriteHeader(status int) {
+	if status == http.StatusBadRequest && w.body.exceeded {
+		status = http.StatusRequestEntityTooLarge
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Flush passes flushes through for streaming handlers.
+func (w *bodyLimitWriter) Flush() {
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *bodyLimitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// limitBody caps request bodies at maxBodyBytes.
+func limitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, maxBodyBytes)}
+		r.Body = body
+		next.ServeHTTP(&bodyLimitWriter{ResponseWriter: w, body: body}, r)
+	})
+}
+
+// deckZones are the fields that hold lists of card entries: a deck's zones
+// and the cube list sent with a deck to validate-cube.
+var deckZones = map[string]bool{
+	"cards": true, "sideboard": true, "maybeboard": true,
+	"commanders": true, "battlefields": true, "runeDeck": true,
+	"cube": true,
+}
+
+// entryFrame is an open object or array while counting card entries.
+type entryFrame struct {
+	delim json.Delim
+	// key is the object's current key, and expectKey is true while the
+	// next token is a key.
+	key       string
+	expectKey bool
+	// zone is true for an array holding a deck's card entries.
+	zone bool
+}
+
+// countCardEntries scans a JSON document token by token and counts the
+// entries of every array under one of deckZones' keys, however deeply it
+// is nested, so decks sent bare, in batch arrays or under a key such as
+// {"deck": ...} are all counted. It stops with errTooManyCardEntries as
+// soon as the count goes over maxCardEntries, without reading the rest.
+// Malformed JSON ends the scan without an error; the handler reports it
+// when it decodes the request.
+func countCardEntries(r io.Reader) (int, error) {
+	dec := json.NewDecoder(r)
+	var stack []entryFrame
+	n := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return n, err
+			}
+			return n, nil
+		}
+		var top *entryFrame
+		if len(stack) > 0 {
+			top = &stack[len(stack)-1]
+		}
+		if key, ok := tok.(string); ok && top != nil && top.delim == '{' && top.expectKey {
+			top.key, top.expectKey = key, false
+			continue
+		}
+		if tok == json.Delim('}') || tok == json.Delim(']') {
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 && stack[len(stack)-1].delim == '{' {
+				stack[len(stack)-1].expectKey = true
+			}
+			continue
+		}
+
+		// tok starts a value inside top.
+		if top != nil && top.zone {
+			if n++; maxCardEntries > 0 && n > maxCardEntries {
+				return n, tooManyCardEntries(n)
+			}
+		}
+		delim, isDelim := tok.(json.Delim)
+		if !isDelim {
+			if top != nil && top.delim == '{' {
+				top.expectKey = true
+			}
+			continue
+		}
+		stack = append(stack, entryFrame{
+			delim:     delim,
+			expectKey: delim == '{',
+			zone:      delim == '[' && top != nil && top.delim == '{' && deckZones[top.key],
+		})
+	}
+}
+
+// deckEntries counts the card entries in a decoded deck.
+func deckEntries(deck *Deck) int {
+	return len(deck.Cards) + len(deck.Sideboard) + len(deck.Maybeboard) +
+		len(deck.Commanders) + len(deck.Battlefields) + len(deck.Runes)
+}
+
+// checkCardEntries returns errTooManyCardEntries when n is over
+// maxCardEntries, for handlers that put together a deck from more than the
+// request body, such as a sideboard parameter or a Markdown code block.
+func checkCardEntries(n int) error {
+	if maxCardEntries > 0 && n > maxCardEntries {
+		return tooManyCardEntries(n)
+	}
+	return nil
+}
+
+// limitCardEntries rejects requests whose deck, from the content parameter
+// or the body, declares more than maxCardEntries card entries with 413,
+// before the handler does any real work on it. The body is counted as it
+// is read, keeping only the bytes read so far for the handler, so a
+// request over the limit is turned away without reading the rest.
+func limitCardEntries(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if maxCardEntries <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var err error
+		if content := r.URL.Query().Get("content"); content != "" {
+			_, err = countCardEntries(bytes.NewReader([]byte(content)))
+		} else if r.Body != nil {
+			var read bytes.Buffer
+			_, err = countCardEntries(io.TeeReader(r.Body, &read))
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(&read, r.Body), r.Body}
+		}
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.Is(err, errTooManyCardEntries):
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		case errors.As(err, &tooLarge):
+			http.Error(w, fmt.Sprintf("request body is larger than %d bytes", maxBodyBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCountCardEntries(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"bare deck", `{"cards":[{"name":"A"},{"name":"B"}],"sideboard":[{"name":"C"}]}`, 3},
+		{"batch array", `[{"cards":[{"name":"A"}]},{"cards":[{"name":"B"},{"name":"C"}]}]`, 3},
+		{"deck and cube", `{"deck":{"cards":[{"name":"A"}]},"cube":[{"name":"A"},{"name":"B"}]}`, 3},
+		{"edit request", `{"deck":{"cards":[{"name":"A"},{"name":"B"}]},"edit":{"op":"add","card":"C"}}`, 2},
+		{"combo request", `{"deck":{"cards":[{"name":"A"}],"commanders":[{"name":"B"}]},"cardA":"A","cardB":"B","turn":3}`, 2},
+		{"deeply nested", `{"a":{"b":[{"c":{"cards":[{"name":"A"},{"name":"B"}]}}]}}`, 2},
+		{"faces aren't entries", `{"cards":[{"name":"Fire // Ice","faces":["Fire","Ice"]}]}`, 1},
+		{"scalars in a zone", `{"cards":[1,2,3]}`, 3},
+		{"unrelated arrays", `{"tags":["a","b"],"cards":[]}`, 0},
+		{"malformed", `{"cards":[{"name":"A"},`, 1},
+	}
+	for _, tt := range tests {
+		got, err := countCardEntries(strings.NewReader(tt.body))
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: counted %d entries, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCountCardEntriesLimit(t *testing.T) {
+	saved := maxCardEntries
+	defer func() { maxCardEntries = saved }()
+	maxCardEntries = 3
+
+	tests := []struct {
+		name string
+		body string
+		want bool // whether the limit is exceeded
+	}{
+		{"at the limit", `{"cards":[{},{},{}]}`, false},
+		{"over in one zone", `{"cards":[{},{},{},{}]}`, true},
+		{"over across zones", `{"cards":[{},{}],"sideboard":[{},{}]}`, true},
+		{"over with the cube", `{"deck":{"cards":[{},{}]},"cube":[{},{}]}`, true},
+		{"over across a batch", `[{"cards":[{},{}]},{"cards":[{},{}]}]`, true},
+		{"over when nested", `{"deck":{"cards":[{},{},{},{}]}}`, true},
+	}
+	for _, tt := range tests {
+		_, err := countCardEntries(strings.NewReader(tt.body))
+		if got := errors.Is(err, errTooManyCardEntries); got != tt.want {
+			t.Errorf("%s: exceeded = %v, want %v (err %v)", tt.name, got, tt.want, err)
+		}
+	}
+}
+
+func TestLimitCardEntriesHandlers(t *testing.T) {
+	saved := maxCardEntries
+	defer func() { maxCardEntries = saved }()
+	maxCardEntries = 3
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		target  string
+		body    string
+		want    int
+	}{
+		{"validate-cube", validateCubeHandler, "/validate-cube",
+			`{"deck":{"cards":[{"name":"A","count":1}]},"cube":[{"name":"A"},{"name":"B"},{"name":"C"}]}`, http.StatusRequestEntityTooLarge},
+		{"validate-cube within the limit", validateCubeHandler, "/validate-cube",
+			`{"deck":{"cards":[{"name":"A","count":1}]},"cube":[{"name":"A"},{"name":"B"}]}`, http.StatusOK},
+		{"sideboard parameter", validateDeckHandler,
+			`/validate?sideboard=` + `%5B%7B%22name%22%3A%22C%22%7D%2C%7B%22name%22%3A%22D%22%7D%5D`,
+			`{"game":"mtg","cards":[{"name":"A","count":1},{"name":"B","count":1}]}`, http.StatusRequestEntityTooLarge},
+		{"parse-markdown", parseMarkdownHandler, "/parse-markdown",
+			"# Deck\n\n```json\n{\"game\":\"mtg\",\"cards\":[{\"name\":\"A\"},{\"name\":\"B\"},{\"name\":\"C\"},{\"name\":\"D\"}]}\n```\n", http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+		rec := httptest.NewRecorder()
+		limitCardEntries(tt.handler).ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, rec.Code, tt.want, strings.TrimSpace(rec.Body.String()))
+		}
+	}
+}
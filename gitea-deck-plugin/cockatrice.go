@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// cockatriceDeck mirrors Cockatrice's .cod deck file.
+type cockatriceDeck struct {
+	XMLName  xml.Name         `xml:"cockatrice_deckfile"`
+	Version  string           `xml:"version,attr"`
+	DeckName string           `xml:"deckname"`
+	Comments string           `xml:"comments"`
+	Zones    []cockatriceZone `xml:"zone"`
+}
+
+type cockatriceZone struct {
+	Name  string           `xml:"name,attr"`
+	Cards []cockatriceCard `xml:"card"`
+}
+
+type cockatriceCard struct {
+	Number int    `xml:"number,attr"`
+	Name   string `xml:"name,attr"`
+}
+
+// exportCockatrice renders the deck as a Cockatrice .cod file. Cockatrice
+// identifies cards by name, so entries without a name fall back to their ID.
+func exportCockatrice(deck *Deck) (string, error) {
+	cod := cockatriceDeck{
+		Version:  "1",
+		DeckName: deck.Name,
+		Comments: deck.Metadata.Description,
+		Zones: []cockatriceZone{
+			{Name: "main", Cards: cockatriceCards(deck.Cards)},
+		},
+	}
+	if len(deck.Sideboard) > 0 {
+		cod.Zones = append(cod.Zones, cockatriceZone{Name: "side", Cards: cockatriceCards(deck.Sideboard)})
+	}
+
+	out, err := xml.MarshalIndent(cod, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(out) + "\n", nil
+}
+
+func cockatriceCards(cards []DeckCard) []cockatriceCard {
+	out := make([]cockatriceCard, 0, len(cards))
+	for _, card := range cards {
+		name := card.Name
+		if name == "" {
+			name = card.ID
+		}
+		out = append(out, cockatriceCard{Number: card.Count, Name: name})
+	}
+	return out
+}
+
+// importCockatrice parses a Cockatrice .cod file. The main and side zones
+// become Cards and Sideboard; other zones (such as tokens) are ignored.
+func importCockatrice(data []byte) (*Deck, error) {
+	var cod cockatriceDeck
+	if err := xml.Unmarshal(data, &cod); err != nil {
+		return nil, fmt.Errorf("invalid Cockatrice deck XML: %w", err)
+	}
+
+	deck := &Deck{
+		Game: "mtg",
+		Name: strings.TrimSpace(cod.DeckName),
+		Metadata: DeckMetadata{
+			Description: strings.TrimSpace(cod.Comments),
+		},
+	}
+	for _, zone := range cod.Zones {
+		var cards []DeckCard
+		for _, c := range zone.Cards {
+			if c.Name == "" || c.Number <= 0 {
+				return nil, fmt.Errorf("invalid Cockatrice deck: card entry in zone %q needs a name and a positive number", zone.Name)
+			}
+			cards = append(cards, DeckCard{Name: c.Name, Count: c.Number})
+		}
+		switch zone.Name {
+		case "main":
+			deck.Cards = append(deck.Cards, cards...)
+		case "side":
+			deck.Sideboard = append(deck.Sideboard, cards...)
+		}
+	}
+	return deck, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// collectionColumns maps normalized CSV header names, as exported by common
+// collection apps, to the DeckCard field they fill.
+var collectionColumns = map[string]string{
+	"name":       "name",
+	"card":       "name",
+	"cardname":   "name",
+	"quantity":   "count",
+	"qty":        "count",
+	"count":      "count",
+	"amount":     "count",
+	"copies":     "count",
+	"set":        "set",
+	"setcode":    "set",
+	"edition":    "set",
+	"id":         "id",
+	"cardid":     "id",
+	"scryfallid": "id",
+}
+
+// maxRowErrors is how many malformed rows a CSV import reports before
+// giving up.
+const maxRowErrors = 20
+
+// normalizeColumn lower-cases a header and drops spaces, underscores and
+// hyphens, so "Card Name", "card_name" and "card-name" match.
+func normalizeColumn(header string) string {
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(header)))
+}
+
+// importCollectionCSV reads a collection CSV with at least name and
+// quantity columns. A first row naming both columns is taken as the header;
+// otherwise the file must be headerless "quantity,name" or "name,quantity"
+// rows. Malformed rows are reported together, by line number.
+func importCollectionCSV(r io.Reader) ([]DeckCard, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing CSV: %w", err)
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	columns := map[string]int{}
+	for i, name := range header {
+		if field, ok := collectionColumns[normalizeColumn(name)]; ok {
+			if _, dup := columns[field]; !dup {
+				columns[field] = i
+			}
+		}
+	}
+	_, hasName := columns["name"]
+	_, hasCount := columns["count"]
+	// pending holds the first row when it turns out not to be a header.
+	var pending []string
+	if !hasName || !hasCount {
+		// No header: tell the columns apart by which one is a number.
+		if len(header) < 2 {
+			return nil, errors.New("CSV needs name and quantity columns")
+		}
+		columns = map[string]int{"count": 0, "name": 1}
+		if _, err := strconv.Atoi(strings.TrimSpace(header[0])); err != nil {
+			columns = map[string]int{"name": 0, "count": 1}
+		}
+		pending = header
+	}
+
+	field := func(row []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	cards := []DeckCard{}
+	var rowErrors []string
+	for {
+		row := pending
+		if row == nil {
+			if row, err = reader.Read(); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("parsing CSV: %w", err)
+			}
+		}
+		pending = nil
+		line, _ := reader.FieldPos(0)
+		if len(row) == 1 && strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+
+		card := DeckCard{Name: field(row, "name"), Set: field(row, "set"), ID: field(row, "id")}
+		count, err := strconv.Atoi(field(row, "count"))
+		switch {
+		case card.Name == "":
+			rowErrors = append(rowErrors, fmt.Sprintf("line %d: missing card name", line))
+		case err != nil || count <= 0:
+			rowErrors = append(rowErrors, fmt.Sprintf("line %d: quantity %q is not a positive number", line, field(row, "count")))
+		default:
+			if maxCardEntries > 0 && len(cards) == maxCardEntries {
+				return nil, tooManyCardEntries(len(cards) + 1)
+			}
+			card.Count = count
+			cards = append(cards, card)
+		}
+		if len(rowErrors) == maxRowErrors {
+			rowErrors = append(rowErrors, "further rows not checked")
+			break
+		}
+	}
+	if len(rowErrors) > 0 {
+		return nil, fmt.Errorf("malformed rows: %s", strings.Join(rowErrors, "; "))
+	}
+	return cards, nil
+}
+
+type collectionResponse struct {
+	Cards  []DeckCard `json:"cards"`
+	Copies int        `json:"copies"`
+}
+
+func importCollectionHandler(w http.ResponseWriter, r *http.Request) {
+	if format := r.URL.Query().Get("format"); format != "" && format != "csv" {
+		http.Error(w, fmt.Sprintf("unsupported collection format %q", format), http.StatusBadRequest)
+		return
+	}
+
+	cards, err := importCollectionCSV(r.Body)
+	if errors.Is(err, errTooManyCardEntries) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := collectionResponse{Cards: cards}
+	for _, card := range cards {
+		resp.Copies += card.Count
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
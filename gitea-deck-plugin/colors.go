@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// mtgColors lists the MTG colors in WUBRG order.
+var mtgColors = []string{"W", "U", "B", "R", "G"}
+
+// colorDistribution counts color symbols across the main deck and
+// commanders, weighted by copies. Each of W/U/B/R/G counts the copies of
+// cards of that color, so a multicolor card counts towards each of its
+// colors and also towards "multicolor". Cards whose colors are an explicit
+// empty list (or ["C"]) count as "colorless"; cards with no color data at
+// all count as "unknown".
+func colorDistribution(deck *Deck) map[string]int {
+	dist := map[string]int{
+		"W": 0, "U": 0, "B": 0, "R": 0, "G": 0,
+		"multicolor": 0,
+		"colorless":  0,
+		"unknown":    0,
+	}
+
+	cards := append(commandersOf(deck), deck.Cards...)
+	for _, card := range cards {
+		if card.Colors == nil {
+			dist["unknown"] += card.Count
+			continue
+		}
+		colors := 0
+		for _, c := range card.Colors {
+			c = strings.ToUpper(c)
+			if isMTGColor(c) {
+				dist[c] += card.Count
+				colors++
+			}
+		}
+		switch {
+		case colors == 0:
+			dist["colorless"] += card.Count
+		case colors > 1:
+			dist["multicolor"] += card.Count
+		}
+	}
+	return dist
+}
+
+func isMTGColor(c string) bool {
+	for _, color := range mtgColors {
+		if c == color {
+			return true
+		}
+	}
+	return false
+}
+
+type colorsResponse struct {
+	Distribution  map[string]int `json:"distribution"`
+	ColorIdentity []string       `json:"colorIdentity"`
+}
+
+func deckColorsHandler(w http.ResponseWriter, r *http.Request) {
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	dist := colorDistribution(deck)
+	resp := colorsResponse{Distribution: dist, ColorIdentity: []string{}}
+	for _, c := range mtgColors {
+		if dist[c] > 0 {
+			resp.ColorIdentity = append(resp.ColorIdentity, c)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// groupByColor sorts the commanders and main deck into the buckets used by
+// colorDistribution. Unlike the distribution, each card lands in exactly one
+// bucket: its color if it has one, "multicolor" if it has several.
+func groupByColor(deck *Deck) map[string][]DeckCard {
+	groups := map[string][]DeckCard{}
+	for _, card := range append(commandersOf(deck), deck.Cards...) {
+		if card.Colors == nil {
+			groups["unknown"] = append(groups["unknown"], card)
+			continue
+		}
+		var colors []string
+		for _, c := range card.Colors {
+			if c = strings.ToUpper(c); isMTGColor(c) {
+				colors = append(colors, c)
+			}
+		}
+		switch len(colors) {
+		case 0:
+			groups["colorless"] = append(groups["colorless"], card)
+		case 1:
+			groups[colors[0]] = append(groups[colors[0]], card)
+		default:
+			groups["multicolor"] = append(groups["multicolor"], card)
+		}
+	}
+	return groups
+}
+
+type colorGroup struct {
+	Count int        `json:"count"`
+	Cards []DeckCard `json:"cards"`
+}
+
+type byColorResponse struct {
+	Groups   map[string]colorGroup `json:"groups"`
+	Warnings []string              `json:"warnings"`
+}
+
+func deckByColorHandler(w http.ResponseWriter, r *http.Request) {
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := byColorResponse{Groups: map[string]colorGroup{}, Warnings: []string{}}
+	for bucket, cards := range groupByColor(deck) {
+		group := colorGroup{Cards: cards}
+		for _, card := range cards {
+			group.Count += card.Count
+		}
+		resp.Groups[bucket] = group
+	}
+	if unknown := resp.Groups["unknown"].Count; unknown > 0 {
+		resp.Warnings = append(resp.Warnings, fmt.Sprintf("%d cards have no color data", unknown))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// colorIdentity is the set of MTG colors across cards. ok is false when any
+// of the cards has no color data.
+func colorIdentity(cards []DeckCard) (identity map[string]bool, ok bool) {
+	identity = map[string]bool{}
+	for _, card := range cards {
+		if card.Colors == nil {
+			return nil, false
+		}
+		for _, c := range card.Colors {
+			if c = strings.ToUpper(c); isMTGColor(c) {
+				identity[c] = true
+			}
+		}
+	}
+	return identity, true
+}
+
+// checkColorIdentity errors for every main deck card with a color outside
+// the commanders' combined color identity, so a commander and its partner
+// or background share one identity. It is skipped when a commander has no
+// color data; main deck cards without color data are not checked.
+func checkColorIdentity(deck *Deck, result *ValidationResult) {
+	cmdrs := commandersOf(deck)
+	if len(cmdrs) == 0 {
+		return
+	}
+	identity, ok := colorIdentity(cmdrs)
+	if !ok {
+		return
+	}
+	for _, card := range deck.Cards {
+		if outside := outsideIdentity(card, identity); outside != "" {
+			result.addError(CodeColorIdentity, displayName(card), displayName(card), outside, identityString(identity))
+		}
+	}
+}
+
+// outsideIdentity lists, in WUBRG order, the card's colors that aren't in
+// identity.
+func outsideIdentity(card DeckCard, identity map[string]bool) string {
+	colors := map[string]bool{}
+	for _, c := range card.Colors {
+		colors[strings.ToUpper(c)] = true
+	}
+	outside := ""
+	for _, c := range mtgColors {
+		if colors[c] && !identity[c] {
+			outside += c
+		}
+	}
+	return outside
+}
+
+// identityString writes a color identity in WUBRG order, or "C" for
+// colorless.
+func identityString(identity map[string]bool) string {
+	s := ""
+	for _, c := range mtgColors {
+		if identity[c] {
+			s += c
+		}
+	}
+	if s == "" {
+		return "C"
+	}
+	return s
+}
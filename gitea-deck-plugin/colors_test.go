@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestColorIdentityWithBackground(t *testing.T) {
+	commander := &DeckCard{Name: "Lae'zel, Vlaakith's Champion", Count: 1, Colors: []string{"W"}, PairType: "choose-a-background"}
+	background := DeckCard{Name: "Cultist of the Absolute", Count: 1, Colors: []string{"B"}, PairType: "background"}
+	tests := []struct {
+		card DeckCard
+		want bool // whether the card is outside the identity
+	}{
+		{DeckCard{Name: "Swords to Plowshares", Count: 1, Colors: []string{"W"}}, false},
+		{DeckCard{Name: "Vindicate", Count: 1, Colors: []string{"W", "B"}}, false},
+		{DeckCard{Name: "Sol Ring", Count: 1, Colors: []string{}}, false},
+		{DeckCard{Name: "Counterspell", Count: 1, Colors: []string{"U"}}, true},
+		{DeckCard{Name: "Dimir Charm", Count: 1, Colors: []string{"U", "B"}}, true},
+	}
+	for _, tt := range tests {
+		deck := &Deck{
+			Game:       "mtg",
+			Format:     "commander",
+			Commander:  commander,
+			Commanders: []DeckCard{background},
+			Cards:      []DeckCard{tt.card},
+		}
+		var result ValidationResult
+		checkColorIdentity(deck, &result)
+		if got := hasIssue(result, CodeColorIdentity, tt.card.Name); got != tt.want {
+			t.Errorf("%s with a W commander and B background: flagged = %v, want %v (errors %v)", tt.card.Name, got, tt.want, result.Errors)
+		}
+	}
+}
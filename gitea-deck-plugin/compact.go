@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CompactDeck is a smaller form of Deck for bandwidth-sensitive clients.
+// Each card is written as a "4x id" string, or "4x name:Card Name" when it
+// has no ID; the name and card data of cards with an ID are dropped.
+// Metadata is left out when it is empty.
+type CompactDeck struct {
+	Game       string        `json:"game"`
+	Format     string        `json:"format,omitempty"`
+	Name       string        `json:"name,omitempty"`
+	Cards      []string      `json:"cards"`
+	Sideboard  []string      `json:"sideboard,omitempty"`
+	Maybeboard []string      `json:"maybeboard,omitempty"`
+	Metadata   *DeckMetadata `json:"metadata,omitempty"`
+
+	Commander      string   `json:"commander,omitempty"`
+	Commanders     []string `json:"commanders,omitempty"`
+	Companion      string   `json:"companion,omitempty"`
+	Oathbreaker    string   `json:"oathbreaker,omitempty"`
+	SignatureSpell string   `json:"signatureSpell,omitempty"`
+
+	Legend       string   `json:"legend,omitempty"`
+	Battlefield  string   `json:"battlefield,omitempty"`
+	Battlefields []string `json:"battlefields,omitempty"`
+	Runes        []string `json:"runeDeck,omitempty"`
+}
+
+// compactNamePrefix marks a compact entry that refers to a card by name.
+const compactNamePrefix = "name:"
+
+// compactDeck converts deck to its compact form.
+func compactDeck(deck *Deck) CompactDeck {
+	compact := CompactDeck{
+		Game:           deck.Game,
+		Format:         deck.Format,
+		Name:           deck.Name,
+		Cards:          compactCards(deck.Cards),
+		Sideboard:      compactCards(deck.Sideboard),
+		Maybeboard:     compactCards(deck.Maybeboard),
+		Commander:      compactCardPtr(deck.Commander),
+		Commanders:     compactCards(deck.Commanders),
+		Companion:      compactCardPtr(deck.Companion),
+		Oathbreaker:    compactCardPtr(deck.Oathbreaker),
+		SignatureSpell: compactCardPtr(deck.SignatureSpell),
+		Legend:         compactCardPtr(deck.Legend),
+		Battlefield:    compactCardPtr(deck.Battlefield),
+		Battlefields:   compactCards(deck.Battlefields),
+		Runes:          compactCards(deck.Runes),
+	}
+	if compact.Cards == nil {
+		compact.Cards = []string{}
+	}
+	if !metadataEmpty(deck.Metadata) {
+		metadata := deck.Metadata
+		compact.Metadata = &metadata
+	}
+	return compact
+}
+
+func metadataEmpty(m DeckMetadata) bool {
+	return m.Author == "" && m.Created == "" && m.Updated == "" && m.Description == "" &&
+		len(m.Tags) == 0 && len(m.Overrides) == 0 && m.Bracket == 0
+}
+
+func compactCard(card DeckCard) string {
+	if card.ID != "" {
+		return fmt.Sprintf("%dx %s", card.Count, card.ID)
+	}
+	return fmt.Sprintf("%dx %s%s", card.Count, compactNamePrefix, card.Name)
+}
+
+func compactCards(cards []DeckCard) []string {
+	if len(cards) == 0 {
+		return nil
+	}
+	out := make([]string, len(cards))
+	for i, card := range cards {
+		out[i] = compactCard(card)
+	}
+	return out
+}
+
+func compactCardPtr(card *DeckCard) string {
+	if card == nil {
+		return ""
+	}
+	return compactCard(*card)
+}
+
+// expandDeck reverses compactDeck.
+func expandDeck(compact CompactDeck) (*Deck, error) {
+	deck := &Deck{Game: compact.Game, Format: compact.Format, Name: compact.Name}
+	if compact.Metadata != nil {
+		deck.Metadata = *compact.Metadata
+	}
+
+	var err error
+	zones := []struct {
+		entries []string
+		cards   *[]DeckCard
+	}{
+		{compact.Cards, &deck.Cards},
+		{compact.Sideboard, &deck.Sideboard},
+		{compact.Maybeboard, &deck.Maybeboard},
+		{compact.Commanders, &deck.Commanders},
+		{compact.Battlefields, &deck.Battlefields},
+		{compact.Runes, &deck.Runes},
+	}
+	for _, zone := range zones {
+		if *zone.cards, err = expandCards(zone.entries); err != nil {
+			return nil, err
+		}
+	}
+	if deck.Cards == nil {
+		deck.Cards = []DeckCard{}
+	}
+
+	singles := []struct {
+		entry string
+		card  **DeckCard
+	}{
+		{compact.Commander, &deck.Commander},
+		{compact.Companion, &deck.Companion},
+		{compact.Oathbreaker, &deck.Oathbreaker},
+		{compact.SignatureSpell, &deck.SignatureSpell},
+		{compact.Legend, &deck.Legend},
+		{compact.Battlefield, &deck.Battlefield},
+	}
+	for _, single := range singles {
+		if single.entry == "" {
+			continue
+		}
+		card, err := expandCard(single.entry)
+		if err != nil {
+			return nil, err
+		}
+		*single.card = &card
+	}
+	return deck, nil
+}
+
+func expandCards(entries []string) ([]DeckCard, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	cards := make([]DeckCard, len(entries))
+	for i, entry := range entries {
+		card, err := expandCard(entry)
+		if err != nil {
+			return nil, err
+		}
+		cards[i] = card
+	}
+	return cards, nil
+}
+
+// expandCard parses one "4x id" or "4x name:Card Name" entry.
+func expandCard(entry string) (DeckCard, error) {
+	count, ref, ok := strings.Cut(strings.TrimSpace(entry), "x ")
+	n, err := strconv.Atoi(count)
+	if !ok || err != nil || n < 0 || strings.TrimSpace(ref) == "" {
+		return DeckCard{}, fmt.Errorf("invalid compact card %q, want \"<count>x <id>\"", entry)
+	}
+	ref = strings.TrimSpace(ref)
+	if name, ok := strings.CutPrefix(ref, compactNamePrefix); ok {
+		return DeckCard{Count: n, Name: name}, nil
+	}
+	return DeckCard{Count: n, ID: ref}, nil
+}
+
+// importCompact parses a deck in the compact form written by compactDeck.
+func importCompact(data []byte) (*Deck, error) {
+	var compact CompactDeck
+	if err := json.Unmarshal(data, &compact); err != nil {
+		return nil, fmt.Errorf("invalid compact deck JSON: %w", err)
+	}
+	return expandDeck(compact)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// companionRestriction is a companion's deckbuilding condition. check is
+// given the deck's minimum size and returns the names of cards that break
+// it and how many cards couldn't be checked for lack of type data.
+type companionRestriction struct {
+	Rule  string
+	check func(deck *Deck, minSize int) (violations []string, unchecked int)
+}
+
+// companionRestrictions is keyed by companion name. Companions whose
+// condition depends on data we don't carry (mana symbols, activated
+// abilities) are left out and reported as uncheckable.
+var companionRestrictions = map[string]companionRestriction{
+	"Lurrus of the Dream-Den": {
+		Rule: "Each permanent card in your starting deck has mana value 2 or less",
+		check: perCardRestriction(func(c DeckCard) bool {
+			return !isPermanent(c) || c.CMC <= 2
+		}),
+	},
+	"Gyruda, Doom of Depths": {
+		Rule: "Your starting deck contains only cards with even mana values",
+		check: perCardRestriction(func(c DeckCard) bool {
+			return hasType(c, "land") || int(c.CMC)%2 == 0
+		}),
+	},
+	"Obosh, the Preypiercer": {
+		Rule: "Your starting deck contains only cards with odd mana values and land cards",
+		check: perCardRestriction(func(c DeckCard) bool {
+			return hasType(c, "land") || int(c.CMC)%2 == 1
+		}),
+	},
+	"Keruga, the Macrosage": {
+		Rule: "Your starting deck contains only cards with mana value 3 or greater and land cards",
+		check: perCardRestriction(func(c DeckCard) bool {
+			return hasType(c, "land") || c.CMC >= 3
+		}),
+	},
+	"Kaheera, the Orphanguard": {
+		Rule: "Each creature card in your starting deck is a Cat, Elemental, Nightmare, Dinosaur, or Beast card",
+		check: perCardRestriction(func(c DeckCard) bool {
+			if !hasType(c, "creature") {
+				return true
+			}
+			for _, t := range []string{"cat", "elemental", "nightmare", "dinosaur", "beast"} {
+				if hasType(c, t) {
+					return true
+				}
+			}
+			return false
+		}),
+	},
+	"Lutri, the Spellchaser": {
+		Rule: "Each nonland card in your starting deck has a different name",
+		check: func(deck *Deck, _ int) ([]string, int) {
+			counts := map[string]int{}
+			var violations []string
+			for _, c := range companionCards(deck) {
+				if hasType(c, "land") || isBasicLand(c.Name) {
+					continue
+				}
+				key := cardKey(deck.Game, c)
+				counts[key] += c.Count
+				if counts[key] > 1 && counts[key]-c.Count <= 1 {
+					violations = append(violations, displayName(c))
+				}
+			}
+			return violations, 0
+		},
+	},
+	"Yorion, Sky Nomad": {
+		Rule: "Your starting deck contains at least twenty cards more than the minimum deck size",
+		check: func(deck *Deck, minSize int) ([]string, int) {
+			total := 0
+			for _, c := range deck.Cards {
+				total += c.Count
+			}
+			if total < minSize+20 {
+				return []string{fmt.Sprintf("deck size %d (needs %d)", total, minSize+20)}, 0
+			}
+			return nil, 0
+		},
+	},
+}
+
+// perCardRestriction builds a check from a predicate that every typed card
+// in the deck must satisfy. Cards without type data are counted as
+// unchecked rather than judged, except basic lands which are recognised by
+// name.
+func perCardRestriction(ok func(DeckCard) bool) func(*Deck, int) ([]string, int) {
+	return func(deck *Deck, _ int) ([]string, int) {
+		var violations []string
+		unchecked := 0
+		for _, c := range companionCards(deck) {
+			if c.Type == "" && isBasicLand(c.Name) {
+				c.Type = "Basic Land"
+			}
+			if c.Type == "" {
+				unchecked += c.Count
+				continue
+			}
+			if !ok(c) {
+				violations = append(violations, displayName(c))
+			}
+		}
+		return violations, unchecked
+	}
+}
+
+// companionCards returns the cards a companion condition applies to: the
+// main deck and any commanders.
+func companionCards(deck *Deck) []DeckCard {
+	return append(commandersOf(deck), deck.Cards...)
+}
+
+// lookupCompanion finds a companion's restriction by normalized name.
+func lookupCompanion(name string) (string, companionRestriction, bool) {
+	key := normalizeName("mtg", name)
+	for companion, restriction := range companionRestrictions {
+		if normalizeName("mtg", companion) == key {
+			return companion, restriction, true
+		}
+	}
+	return "", companionRestriction{}, false
+}
+
+// checkCompanion errors for cards that break the declared companion's
+// deckbuilding condition. minSize is the deck's minimum size after
+// overrides; 0 means the format sets none and 60 is assumed.
+func checkCompanion(deck *Deck, minSize int, result *ValidationResult) {
+	name := displayName(*deck.Companion)
+	companion, restriction, ok := lookupCompanion(name)
+	if !ok {
+		result.addWarning(CodeCompanionUncheckable, name, name)
+		return
+	}
+
+	if minSize <= 0 {
+		minSize = 60
+	}
+	violations, unchecked := restriction.check(deck, minSize)
+	for _, v := range violations {
+		result.addError(CodeCompanionViolation, v, v, companion, restriction.Rule)
+	}
+	if unchecked > 0 {
+		result.addWarning(CodeCompanionUnchecked, "", companion, unchecked)
+	}
+}
+
+// hasType reports whether the card's type line contains t, ignoring case.
+func hasType(card DeckCard, t string) bool {
+	return strings.Contains(strings.ToLower(card.Type), strings.ToLower(t))
+}
+
+func isPermanent(card DeckCard) bool {
+	for _, t := range []string{"creature", "artifact", "enchantment", "planeswalker", "land", "battle"} {
+		if hasType(card, t) {
+			return true
+		}
+	}
+	return false
+}
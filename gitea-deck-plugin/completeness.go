@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// ChecklistItem is one check in a completeness report.
+type ChecklistItem struct {
+	Item   string `json:"item"`
+	Passed bool   `json:"passed"`
+}
+
+// CompletenessReport scores how ready a deck file is for submission:
+// Percent is the share of checklist items that passed.
+type CompletenessReport struct {
+	Percent   int             `json:"percent"`
+	Checklist []ChecklistItem `json:"checklist"`
+}
+
+// completeness runs the submission checklist over a deck: name, author and
+// description, IDs and names on every played card, a legal deck size, and
+// the command zone or legend the format requires.
+func completeness(deck *Deck) CompletenessReport {
+	report := CompletenessReport{Checklist: []ChecklistItem{}}
+	check := func(item string, passed bool) {
+		report.Checklist = append(report.Checklist, ChecklistItem{Item: item, Passed: passed})
+	}
+
+	check("name", strings.TrimSpace(deck.Name) != "")
+	check("author", strings.TrimSpace(deck.Metadata.Author) != "")
+	check("description", strings.TrimSpace(deck.Metadata.Description) != "")
+
+	ids, names := true, true
+	for _, card := range playedCards(deck) {
+		ids = ids && card.ID != ""
+		names = names && strings.TrimSpace(card.Name) != ""
+	}
+	check("card-ids", ids)
+	check("card-names", names)
+
+	size := true
+	for _, issue := range validateDeck(deck, ValidateOptions{SkipAdvisory: true}).Issues {
+		if issue.Code == CodeDeckSizeMismatch || issue.Code == CodeDeckSizeTooSmall {
+			size = false
+		}
+	}
+	check("deck-size", size)
+
+	switch {
+	case deck.Game == "mtg" && canonicalFormat(deck.Format) == "commander":
+		check("commander", len(commandersOf(deck)) > 0)
+	case deck.Game == "mtg" && canonicalFormat(deck.Format) == "oathbreaker":
+		check("oathbreaker", deck.Oathbreaker != nil && deck.SignatureSpell != nil)
+	case deck.Game == "riftbound":
+		check("legend", deck.Legend != nil)
+	}
+
+	passed := 0
+	for _, item := range report.Checklist {
+		if item.Passed {
+			passed++
+		}
+	}
+	report.Percent = int(math.Round(100 * float64(passed) / float64(len(report.Checklist))))
+	return report
+}
+
+func completenessHandler(w http.ResponseWriter, r *http.Request) {
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(completeness(deck))
+}
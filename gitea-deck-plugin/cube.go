@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// cubeRequest is the body of a cube validation request.
+type cubeRequest struct {
+	Deck Deck       `json:"deck"`
+	Cube []DeckCard `json:"cube"`
+}
+
+// validateAgainstCube errors for every card in the deck that isn't in the
+// cube list. Cards match by ID when both entries have one and by name
+// otherwise. Basic lands are always allowed, and each off-cube card is
+// reported once.
+func validateAgainstCube(deck *Deck, cube []DeckCard) ValidationResult {
+	result := newValidationResult()
+
+	ids := map[string]bool{}
+	names := map[string]bool{}
+	for _, card := range cube {
+		if card.ID != "" {
+			ids[card.ID] = true
+		}
+		if card.Name != "" {
+			names[normalizeName(deck.Game, card.Name)] = true
+		}
+	}
+
+	reported := map[string]bool{}
+	for _, card := range playedCards(deck) {
+		if isBasicLand(card.Name) || (card.ID != "" && ids[card.ID]) {
+			continue
+		}
+		if card.Name != "" && names[normalizeName(deck.Game, card.Name)] {
+			continue
+		}
+		key := cardKey(deck.Game, card)
+		if reported[key] {
+			continue
+		}
+		reported[key] = true
+		result.addError(CodeNotInCube, displayName(card), displayName(card))
+	}
+	return result
+}
+
+func validateCubeHandler(w http.ResponseWriter, r *http.Request) {
+	var req cubeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(req.Cube) == 0 {
+		http.Error(w, "cube list required", http.StatusBadRequest)
+		return
+	}
+
+	validation := validateAgainstCube(&req.Deck, req.Cube)
+	localizeResult(w, r, &validation)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(validation)
+}
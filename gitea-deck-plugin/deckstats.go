@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// deckstatsCategories are the type categories a Deckstats export groups
+// the main deck by, in order. A card goes in the first that matches.
+var deckstatsCategories = []string{
+	"Creature", "Planeswalker", "Battle", "Instant", "Sorcery",
+	"Artifact", "Enchantment", "Land",
+}
+
+// exportDeckstats renders the deck as a Deckstats.net text list: one
+// "count [SET] name" line per entry, commanders marked "#!Commander", and
+// the sideboard and companion under a //Sideboard comment. When any main
+// deck card has type data the main deck is grouped under category comments
+// such as //Creature, with untyped cards under //Other; otherwise it is a
+// flat list.
+func exportDeckstats(deck *Deck) (string, error) {
+	var b strings.Builder
+	line := func(card DeckCard, suffix string) {
+		if card.Set != "" {
+			fmt.Fprintf(&b, "%d [%s] %s%s\n", card.Count, strings.ToUpper(card.Set), displayName(card), suffix)
+		} else {
+			fmt.Fprintf(&b, "%d %s%s\n", card.Count, displayName(card), suffix)
+		}
+	}
+	section := func(title string, cards []DeckCard) {
+		if len(cards) == 0 {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("//" + title + "\n")
+		for _, card := range cards {
+			line(card, "")
+		}
+	}
+
+	if cmdrs := commandersOf(deck); len(cmdrs) > 0 {
+		b.WriteString("//Commander\n")
+		for _, card := range cmdrs {
+			if card.Count == 0 {
+				card.Count = 1
+			}
+			line(card, " #!Commander")
+		}
+	}
+
+	typed := false
+	for _, card := range deck.Cards {
+		if card.Type != "" {
+			typed = true
+			break
+		}
+	}
+	if typed {
+		groups := map[string][]DeckCard{}
+		for _, card := range deck.Cards {
+			groups[deckstatsCategory(card)] = append(groups[deckstatsCategory(card)], card)
+		}
+		for _, category := range append(deckstatsCategories, "Other") {
+			section(category, groups[category])
+		}
+	} else {
+		section("Main", deck.Cards)
+	}
+
+	sideboard := append([]DeckCard{}, deck.Sideboard...)
+	if deck.Companion != nil {
+		sideboard = append(sideboard, *deck.Companion)
+	}
+	section("Sideboard", sideboard)
+	return b.String(), nil
+}
+
+// deckstatsCategory returns the first of deckstatsCategories in the card's
+// type line, "Land" for untyped basic lands, or "Other".
+func deckstatsCategory(card DeckCard) string {
+	for _, category := range deckstatsCategories {
+		if hasType(card, category) {
+			return category
+		}
+	}
+	if land, _ := isLand(card); land {
+		return "Land"
+	}
+	return "Other"
+}
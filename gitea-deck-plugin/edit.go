@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// CardEdit adds or removes copies of one card. Zone is "cards" (the
+// default), "sideboard" or "maybeboard"; Card.Count is the number of copies
+// and defaults to 1.
+type CardEdit struct {
+	Op   string   `json:"op"`
+	Zone string   `json:"zone,omitempty"`
+	Card DeckCard `json:"card"`
+}
+
+// applyEdit returns a copy of deck with the edit applied. Adding a card
+// merges with a matching entry or appends a new one; removing at least as
+// many copies as an entry has deletes it. Entries match by ID when both
+// have one and by normalized name otherwise.
+func applyEdit(deck *Deck, edit CardEdit) (*Deck, error) {
+	count := edit.Card.Count
+	if count == 0 {
+		count = 1
+	}
+	if count < 0 {
+		return nil, errors.New("count must be positive")
+	}
+	if edit.Card.ID == "" && edit.Card.Name == "" {
+		return nil, errors.New("card needs an id or name")
+	}
+
+	out := *deck
+	var zone *[]DeckCard
+	switch edit.Zone {
+	case "", "cards":
+		zone = &out.Cards
+	case "sideboard":
+		zone = &out.Sideboard
+	case "maybeboard":
+		zone = &out.Maybeboard
+	default:
+		return nil, fmt.Errorf("unknown zone %q", edit.Zone)
+	}
+	cards := append([]DeckCard{}, *zone...)
+
+	match := -1
+	for i, card := range cards {
+		if card.ID != "" && edit.Card.ID != "" {
+			if card.ID == edit.Card.ID {
+				match = i
+				break
+			}
+			continue
+		}
+		if cardKey(deck.Game, card) == cardKey(deck.Game, edit.Card) {
+			match = i
+			break
+		}
+	}
+
+	switch edit.Op {
+	case "add":
+		if match >= 0 {
+			cards[match].Count += count
+		} else {
+			card := edit.Card
+			card.Count = count
+			cards = append(cards, card)
+		}
+	case "remove":
+		if match < 0 {
+			return nil, fmt.Errorf("%s is not in %s", displayName(edit.Card), zoneName(edit.Zone))
+		}
+		if cards[match].Count <= count {
+			cards = append(cards[:match], cards[match+1:]...)
+		} else {
+			cards[match].Count -= count
+		}
+	default:
+		return nil, fmt.Errorf("unknown op %q", edit.Op)
+	}
+
+	*zone = cards
+	return &out, nil
+}
+
+func zoneName(zone string) string {
+	if zone == "" {
+		return "cards"
+	}
+	return zone
+}
+
+type applyEditRequest struct {
+	Deck Deck     `json:"deck"`
+	Edit CardEdit `json:"edit"`
+}
+
+type applyEditResponse struct {
+	Deck       *Deck            `json:"deck"`
+	Validation ValidationResult `json:"validation"`
+}
+
+func applyEditHandler(w http.ResponseWriter, r *http.Request) {
+	var req applyEditRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	deck, err := applyEdit(&req.Deck, req.Edit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	validation := validateDeck(deck, validateOptionsFromRequest(r))
+	localizeResult(w, r, &validation)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(applyEditResponse{Deck: deck, Validation: validation})
+}
+
+// EditPreview is the outcome of a set of edits: the edited deck, its
+// validation result, and the errors the edits introduced or fixed compared
+// with the deck as it was.
+type EditPreview struct {
+	Deck       *Deck            `json:"deck"`
+	Validation ValidationResult `json:"validation"`
+	Gained     []Issue          `json:"gained"`
+	Resolved   []Issue          `json:"resolved"`
+}
+
+// previewEdits applies edits in order to a copy of deck and validates the
+// result. Errors are matched by code and card, so an error whose counts
+// change but which still applies is neither gained nor resolved.
+func previewEdits(deck *Deck, edits []CardEdit, opts ValidateOptions) (EditPreview, error) {
+	edited := deck
+	for i, edit := range edits {
+		var err error
+		if edited, err = applyEdit(edited, edit); err != nil {
+			return EditPreview{}, fmt.Errorf("edit %d: %w", i, err)
+		}
+	}
+
+	before := validateDeck(deck, opts)
+	after := validateDeck(edited, opts)
+	return EditPreview{
+		Deck:       edited,
+		Validation: after,
+		Gained:     errorsMissingFrom(after.Issues, before.Issues),
+		Resolved:   errorsMissingFrom(before.Issues, after.Issues),
+	}, nil
+}
+
+// errorsMissingFrom returns the errors in issues with no error of the same
+// code and card in other.
+func errorsMissingFrom(issues, other []Issue) []Issue {
+	type key struct{ code, card string }
+	present := map[key]bool{}
+	for _, issue := range other {
+		if issue.Severity == SeverityError {
+			present[key{issue.Code, issue.Card}] = true
+		}
+	}
+	out := []Issue{}
+	for _, issue := range issues {
+		if issue.Severity == SeverityError && !present[key{issue.Code, issue.Card}] {
+			out = append(out, issue)
+		}
+	}
+	return out
+}
+
+type previewEditRequest struct {
+	Deck  Deck       `json:"deck"`
+	Edits []CardEdit `json:"edits"`
+}
+
+func previewEditHandler(w http.ResponseWriter, r *http.Request) {
+	var req previewEditRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	preview, err := previewEdits(&req.Deck, req.Edits, validateOptionsFromRequest(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	localizeResult(w, r, &preview.Validation)
+	locale := w.Header().Get("Content-Language")
+	for _, issues := range [][]Issue{preview.Gained, preview.Resolved} {
+		for i := range issues {
+			issues[i].Message = renderMessage(locale, issues[i].Code, issues[i].args)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
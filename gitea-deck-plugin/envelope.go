@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// envelopeMeta describes the request an enveloped response answers.
+type envelopeMeta struct {
+	RequestID  string  `json:"requestId,omitempty"`
+	DurationMS float64 `json:"durationMs"`
+}
+
+type envelopedData struct {
+	Data json.RawMessage `json:"data"`
+	Meta envelopeMeta    `json:"meta"`
+}
+
+type envelopedError struct {
+	Error map[string]interface{} `json:"error"`
+	Meta  envelopeMeta           `json:"meta"`
+}
+
+// envelopeWriter buffers JSON and error responses so envelope can wrap
+// them. Anything else, such as zip archives, images and NDJSON or event
+// streams, is passed straight through.
+type envelopeWriter struct {
+	http.ResponseWriter
+	status  int
+	decided bool
+	buf     *bytes.Buffer
+}
+
+func (e *envelopeWriter) WriteHeader(status int) {
+	if e.decided {
+		return
+	}
+	e.decided = true
+	e.status = status
+	if status >= 400 || isJSONContentType(e.Header().Get("Content-Type")) {
+		e.buf = &bytes.Buffer{}
+		return
+	}
+	e.ResponseWriter.WriteHeader(status)
+}
+
+func (e *envelopeWriter) Write(p []byte) (int, error) {
+	if !e.decided {
+		e.WriteHeader(http.StatusOK)
+	}
+	if e.buf != nil {
+		return e.buf.Write(p)
+	}
+	return e.ResponseWriter.Write(p)
+}
+
+// Flush passes flushes through for responses that aren't buffered, so
+// handlers asserting http.Flusher can still stream.
+func (e *envelopeWriter) Flush() {
+	if e.buf == nil {
+		http.NewResponseController(e.ResponseWriter).Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (e *envelopeWriter) Unwrap() http.ResponseWriter {
+	return e.ResponseWriter
+}
+
+// isJSONContentType reports whether a Content-Type is a single JSON
+// document, including suffixed types like application/schema+json.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// envelope wraps responses in {"data": ..., "meta": ...}, and errors in
+// {"error": {"status", "message", ...}, "meta": ...}, when the request has
+// envelope=true. Other requests get the bare response.
+func envelope(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("envelope") != "true" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		ew := &envelopeWriter{ResponseWriter: w}
+		next.ServeHTTP(ew, r)
+		if ew.buf == nil {
+			return
+		}
+
+		meta := envelopeMeta{
+			RequestID:  middleware.GetReqID(r.Context()),
+			DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		body := bytes.TrimSpace(ew.buf.Bytes())
+		var resp interface{}
+		switch {
+		case ew.status >= 400:
+			resp = envelopedError{Error: errorDetails(ew.status, w.Header().Get("Content-Type"), body), Meta: meta}
+		case json.Valid(body):
+			resp = envelopedData{Data: body, Meta: meta}
+		default:
+			// Not actually JSON; send it as it was.
+			w.WriteHeader(ew.status)
+			w.Write(ew.buf.Bytes())
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Del("Content-Length")
+		w.WriteHeader(ew.status)
+		json.NewEncoder(w).Encode(resp)
+	})
+}
+
+// errorDetails builds the enveloped error object. Plain text bodies, as
+// written by http.Error, become the message; JSON object bodies keep their
+// fields, with "error" renamed to "message".
+func errorDetails(status int, contentType string, body []byte) map[string]interface{} {
+	details := map[string]interface{}{}
+	if isJSONContentType(contentType) && json.Unmarshal(body, &details) == nil {
+		if msg, ok := details["error"]; ok {
+			details["message"] = msg
+			delete(details, "error")
+		}
+	} else {
+		details["message"] = string(body)
+	}
+	details["status"] = status
+	return details
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ruleReference names the rule behind a card-level issue: a section of the
+// Magic Comprehensive Rules, or the rules file key the check reads. It is ""
+// for codes without a reference.
+func ruleReference(code, format string) string {
+	switch code {
+	case CodeBannedCard, CodeSideboardBannedCard:
+		return "bannedCards." + format
+	case CodeSetNotLegal:
+		return "legalSets." + format
+	case CodeRestrictedCard:
+		return "formats." + format + ".restricted"
+	case CodeRarityLimitExceeded, CodeMissingRarity:
+		return "formats." + format + ".rarityLimits"
+	case CodeBracketCardBanned:
+		return "commanderBrackets"
+	case CodeCopyLimitExceeded, CodeSideboardCopyLimit:
+		if format == "commander" {
+			return "CR 903.5b"
+		}
+		return "CR 100.2a"
+	case CodeColorIdentity:
+		return "CR 903.5c"
+	}
+	return ""
+}
+
+// explainCard validates the deck and returns the issues about the named
+// card, each with its rule reference. A card that isn't in the deck is
+// checked as if one copy were added to the main deck.
+func explainCard(deck *Deck, name string) []Issue {
+	key := normalizeName(deck.Game, name)
+	found := false
+	for _, card := range playedCards(deck) {
+		if cardKey(deck.Game, card) == key {
+			found = true
+			break
+		}
+	}
+	if !found {
+		d := *deck
+		d.Cards = append(append([]DeckCard{}, deck.Cards...), DeckCard{Name: name, Count: 1})
+		deck = &d
+	}
+
+	result := validateDeck(deck, ValidateOptions{SkipAdvisory: true})
+	format := canonicalFormat(deck.Format)
+	issues := []Issue{}
+	for _, issue := range result.Issues {
+		if issue.Card == "" || normalizeName(deck.Game, issue.Card) != key {
+			continue
+		}
+		issue.Rule = ruleReference(issue.Code, format)
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+type explainResponse struct {
+	Card   string  `json:"card"`
+	Legal  bool    `json:"legal"`
+	Issues []Issue `json:"issues"`
+}
+
+func explainCardHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	name := strings.TrimSpace(q.Get("card"))
+	if name == "" {
+		http.Error(w, "card parameter required", http.StatusBadRequest)
+		return
+	}
+
+	deck := &Deck{}
+	if q.Get("content") != "" || r.Method == http.MethodPost {
+		var err error
+		if deck, err = readDeck(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		// Without a deck, the card is explained on its own, as count copies
+		// from set.
+		card := DeckCard{Name: name, Count: 1, Set: q.Get("set")}
+		if v := q.Get("count"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				http.Error(w, "count must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			card.Count = n
+		}
+		deck.Cards = []DeckCard{card}
+	}
+	if game := q.Get("game"); game != "" {
+		deck.Game = strings.ToLower(game)
+	}
+	if format := q.Get("format"); format != "" {
+		deck.Format = format
+	}
+
+	result := ValidationResult{Issues: explainCard(deck, name)}
+	localizeResult(w, r, &result)
+	resp := explainResponse{Card: name, Legal: true, Issues: result.Issues}
+	for _, issue := range resp.Issues {
+		if issue.Severity == SeverityError {
+			resp.Legal = false
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// deckExporter renders a deck in a third-party file format.
+type deckExporter struct {
+	contentType string
+	// extension is the file extension used for the format in batch
+	// archives.
+	extension string
+	export    func(*Deck) (string, error)
+	// warnings, if set, reports problems with the export that don't stop
+	// it, such as data the format needs but the deck lacks.
+	warnings func(*Deck) []string
+}
+
+// exporters maps the export endpoint's format parameter to its exporter.
+var exporters = map[string]deckExporter{
+	"arena":      {contentType: "text/plain; charset=utf-8", extension: ".txt", export: exportArena},
+	"cockatrice": {contentType: "application/xml", extension: ".cod", export: exportCockatrice},
+	"deckstats":  {contentType: "text/plain; charset=utf-8", extension: ".txt", export: exportDeckstats},
+	"mtgo-dek":   {contentType: "application/xml", extension: ".dek", export: exportMTGODek, warnings: mtgoDekWarnings},
+}
+
+// importers maps the import endpoint's format parameter to a parser for
+// that file format.
+var importers = map[string]func([]byte) (*Deck, error){
+	"arena":      importArena,
+	"cockatrice": importCockatrice,
+	"compact":    importCompact,
+	"json":       importJSON,
+}
+
+// importJSON parses a deck in the plugin's own JSON format.
+func importJSON(data []byte) (*Deck, error) {
+	var deck Deck
+	if err := json.Unmarshal(data, &deck); err != nil {
+		return nil, fmt.Errorf("invalid deck JSON: %w", err)
+	}
+	return &deck, nil
+}
+
+// inputExtensions and inputContentTypes map file extensions and media types
+// to import formats. Generic types such as text/plain aren't listed, so
+// those bodies are sniffed instead.
+var inputExtensions = map[string]string{
+	".json": "json",
+	".cod":  "cockatrice",
+	".txt":  "arena",
+}
+
+var inputContentTypes = map[string]string{
+	"application/json": "json",
+	"application/xml":  "cockatrice",
+	"text/xml":         "cockatrice",
+}
+
+// detectInputFormat infers the import format of a deck file from the
+// extension of name (a file name or URL), then from its Content-Type, and
+// finally by sniffing the first non-whitespace byte of data: "{" or "[" for
+// JSON, "<" for Cockatrice XML and a digit for an Arena list.
+func detectInputFormat(name, contentType string, data []byte) (string, error) {
+	if u, err := url.Parse(name); err == nil && u.Path != "" {
+		name = u.Path
+	}
+	if format, ok := inputExtensions[strings.ToLower(path.Ext(name))]; ok {
+		return format, nil
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		if format, ok := inputContentTypes[mediaType]; ok {
+			return format, nil
+		}
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case len(trimmed) == 0:
+		return "", errors.New("empty deck file")
+	case trimmed[0] == '{', trimmed[0] == '[':
+		return "json", nil
+	case trimmed[0] == '<':
+		return "cockatrice", nil
+	case trimmed[0] >= '0' && trimmed[0] <= '9', bytes.HasPrefix(bytes.ToLower(trimmed), []byte("deck")),
+		bytes.HasPrefix(bytes.ToLower(trimmed), []byte("commander")):
+		return "arena", nil
+	}
+	return "", errors.New("can't determine the deck file format; pass ?format=")
+}
+
+// exportWarningsHeader carries one export warning, percent-encoded as UTF-8
+// so card names with commas or non-ASCII characters survive. It is repeated
+// for each warning.
+const exportWarningsHeader = "X-Deck-Warnings"
+
+func exportDeckHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	exporter, ok := exporters[format]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unsupported export format %q", format), http.StatusBadRequest)
+		return
+	}
+
+	content := r.URL.Query().Get("content")
+	if content == "" {
+		http.Error(w, "content parameter required", http.StatusBadRequest)
+		return
+	}
+
+	var deck Deck
+	if err := json.Unmarshal([]byte(content), &deck); err != nil {
+		http.Error(w, fmt.Sprintf("invalid deck JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	out, err := exporter.export(&deck)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("export failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if exporter.warnings != nil {
+		for _, warning := range exporter.warnings(&deck) {
+			w.Header().Add(exportWarningsHeader, strings.ReplaceAll(url.QueryEscape(warning), "+", "%20"))
+		}
+	}
+	w.Header().Set("Content-Type", exporter.contentType)
+	w.Write([]byte(out))
+}
+
+// exportBatchHandler exports every deck in a JSON array and returns them as
+// a zip archive, one file per deck named after the deck.
+func exportBatchHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	exporter, ok := exporters[format]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unsupported export format %q", format), http.StatusBadRequest)
+		return
+	}
+
+	var decks []Deck
+	if err := json.NewDecoder(r.Body).Decode(&decks); err != nil {
+		http.Error(w, fmt.Sprintf("invalid deck JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	used := map[string]bool{}
+	for i := range decks {
+		out, err := exporter.export(&decks[i])
+		if err != nil {
+			http.Error(w, fmt.Sprintf("export of deck %d failed: %v", i, err), http.StatusInternalServerError)
+			return
+		}
+		f, err := zw.Create(archiveName(decks[i].Name, exporter.extension, used))
+		if err == nil {
+			_, err = f.Write([]byte(out))
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("writing archive: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		http.Error(w, fmt.Sprintf("writing archive: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="decks.zip"`)
+	w.Write(buf.Bytes())
+}
+
+// archiveName returns a file name for a deck in a batch archive. Path
+// separators and other characters unsafe in file names are replaced, an
+// empty name becomes "deck", and a name already in used gets "-2", "-3" and
+// so on appended. Names are compared case-insensitively since many file
+// systems do.
+func archiveName(name, ext string, used map[string]bool) string {
+	base := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if base == "" || base == "." || base == ".." {
+		base = "deck"
+	}
+
+	candidate := base + ext
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+func importDeckHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("format") == "sharecode" {
+		importShareCodeHandler(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "only format=sharecode can be imported with GET", http.StatusBadRequest)
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("reading request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if r.URL.Query().Get("compact") == "true" {
+		format = "compact"
+	}
+	if format == "" {
+		format, err = detectInputFormat(r.URL.Query().Get("filename"), r.Header.Get("Content-Type"), data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	importer, ok := importers[format]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unsupported import format %q", format), http.StatusBadRequest)
+		return
+	}
+
+	deck, err := importer(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deck)
+}
+
+// importShareCodeHandler imports the deck in the code query parameter with
+// decodeShareCode, using the decoder named by source if given.
+func importShareCodeHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	code := q.Get("code")
+	if code == "" {
+		http.Error(w, "code parameter required", http.StatusBadRequest)
+		return
+	}
+
+	deck, err := decodeShareCode(q.Get("source"), code)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deck)
+}
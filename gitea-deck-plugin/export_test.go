@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestExportWarningsHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		cards []DeckCard
+		want  []string
+	}{
+		{"all cards have IDs", []DeckCard{{ID: "12345", Name: "Opt", Count: 4}}, nil},
+		{"plain name", []DeckCard{{Name: "Opt", Count: 4}}, []string{"Opt has no ID to use as its MTGO CatID"}},
+		{"comma and quotes", []DeckCard{{Name: `Jace, the "Mind" Sculptor`, Count: 1}}, []string{`Jace, the "Mind" Sculptor has no ID to use as its MTGO CatID`}},
+		{"non-ASCII name", []DeckCard{{Name: "Lim-Dûl's Vault", Count: 1}, {Name: "Jötun Grunt", Count: 1}},
+			[]string{"Lim-Dûl's Vault has no ID to use as its MTGO CatID", "Jötun Grunt has no ID to use as its MTGO CatID"}},
+	}
+	for _, tt := range tests {
+		content, _ := json.Marshal(Deck{Game: "mtg", Cards: tt.cards})
+		rec := httptest.NewRecorder()
+		exportDeckHandler(rec, httptest.NewRequest(http.MethodGet, "/export?format=mtgo-dek&content="+url.QueryEscape(string(content)), nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d (%s)", tt.name, rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Values("Warning"); len(got) != 0 {
+			t.Errorf("%s: Warning header set: %q", tt.name, got)
+		}
+		var got []string
+		for _, value := range rec.Header().Values(exportWarningsHeader) {
+			for _, c := range value {
+				if c < 0x21 || c > 0x7e || c == ',' {
+					t.Errorf("%s: header value %q isn't encoded", tt.name, value)
+					break
+				}
+			}
+			warning, err := url.PathUnescape(value)
+			if err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+			got = append(got, warning)
+		}
+		if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tt.want) {
+			t.Errorf("%s: warnings %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import "flag"
+
+// features are the optional endpoint groups a deployment can turn off with
+// -enable-<name>=false. Parsing, validation (including structure
+// checks, best-format and explain) and the format rules are always served.
+var features = []struct {
+	name        string
+	description string
+}{
+	{"cube", "cube validation"},
+	{"split", "pool split"},
+	{"fixes", "fix suggestion and legality plan"},
+	{"export", "export and batch export"},
+	{"import", "deck and collection import"},
+	{"lint", "lint and completeness"},
+	{"stats", "stats, copy count histogram, power, bracket detection, land and combo probability, simulation and tokens needed"},
+	{"share", "share code and QR"},
+	{"colors", "color distribution and grouping"},
+	{"cards", "card search, index and image"},
+	{"viewer", "deck viewer"},
+}
+
+var enabledFeatures = map[string]*bool{}
+
+// registerFeatureFlags defines an -enable-<name> flag, defaulting to true,
+// for every feature. It must run before flag.Parse.
+func registerFeatureFlags() {
+	for _, f := range features {
+		enabledFeatures[f.name] = flag.Bool("enable-"+f.name, true, "serve the "+f.description+" endpoints")
+	}
+}
+
+// featureEnabled reports whether the named feature's routes should be
+// registered.
+func featureEnabled(name string) bool {
+	return *enabledFeatures[name]
+}
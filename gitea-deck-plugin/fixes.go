@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// DeckEdit is a proposed change that resolves a validation error. Edits that
+// can't be applied mechanically (such as adding unspecified cards) have
+// Fixable set to false and only describe what is needed.
+type DeckEdit struct {
+	Code        string `json:"code"`
+	Op          string `json:"op"`
+	Zone        string `json:"zone,omitempty"`
+	Card        string `json:"card,omitempty"`
+	From        int    `json:"from,omitempty"`
+	To          int    `json:"to,omitempty"`
+	Fixable     bool   `json:"fixable"`
+	Description string `json:"description"`
+}
+
+// suggestFixes proposes one edit per error in the validation result.
+// Errors with no sensible mechanical fix are skipped.
+func suggestFixes(deck *Deck, result ValidationResult) []DeckEdit {
+	edits := []DeckEdit{}
+	for _, issue := range result.Issues {
+		if issue.Severity != SeverityError {
+			continue
+		}
+		switch issue.Code {
+		case CodeCopyLimitExceeded:
+			// args: name, limit, current
+			limit, current := issue.args[1].(int), issue.args[2].(int)
+			edits = append(edits, DeckEdit{
+				Code:        issue.Code,
+				Op:          "set-count",
+				Zone:        "cards",
+				Card:        issue.Card,
+				From:        current,
+				To:          limit,
+				Fixable:     true,
+				Description: fmt.Sprintf("Reduce '%s' from %d to %d", issue.Card, current, limit),
+			})
+		case CodeRarityLimitExceeded:
+			// args: name, limit, rarity, current
+			limit, current := issue.args[1].(int), issue.args[3].(int)
+			edits = append(edits, DeckEdit{
+				Code:        issue.Code,
+				Op:          "set-count",
+				Zone:        "cards",
+				Card:        issue.Card,
+				From:        current,
+				To:          limit,
+				Fixable:     true,
+				Description: fmt.Sprintf("Reduce '%s' from %d to %d", issue.Card, current, limit),
+			})
+		case CodeSideboardNotAllowed:
+			edits = append(edits, DeckEdit{
+				Code:        issue.Code,
+				Op:          "remove-zone",
+				Zone:        "sideboard",
+				Fixable:     true,
+				Description: "Remove the sideboard",
+			})
+		case CodeBannedCard, CodeSideboardBannedCard:
+			edits = append(edits, DeckEdit{
+				Code:        issue.Code,
+				Op:          "remove",
+				Card:        issue.Card,
+				Fixable:     true,
+				Description: fmt.Sprintf("Remove every copy of '%s'", issue.Card),
+			})
+		case CodeSideboardTooLarge:
+			// args: format name, maximum, current
+			max, current := issue.args[1].(int), issue.args[2].(int)
+			edits = append(edits, DeckEdit{
+				Code:        issue.Code,
+				Op:          "cut",
+				Zone:        "sideboard",
+				From:        current,
+				To:          max,
+				Description: fmt.Sprintf("Cut %d sideboard cards", current-max),
+			})
+		case CodeDeckSizeTooSmall, CodeDeckSizeMismatch:
+			// args: format name, required size, current
+			required, current := issue.args[1].(int), issue.args[2].(int)
+			edit := DeckEdit{Code: issue.Code, Zone: "cards", From: current, To: required}
+			if current < required {
+				edit.Op = "add"
+				edit.Description = fmt.Sprintf("Add %d more cards", required-current)
+			} else {
+				edit.Op = "cut"
+				edit.Description = fmt.Sprintf("Cut %d cards", current-required)
+			}
+			edits = append(edits, edit)
+		}
+	}
+	return edits
+}
+
+type suggestFixResponse struct {
+	Validation ValidationResult `json:"validation"`
+	Edits      []DeckEdit       `json:"edits"`
+}
+
+func suggestFixHandler(w http.ResponseWriter, r *http.Request) {
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	validation := validateDeck(deck, validateOptionsFromRequest(r))
+	edits := suggestFixes(deck, validation)
+	localizeResult(w, r, &validation)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suggestFixResponse{
+		Validation: validation,
+		Edits:      edits,
+	})
+}
@@ -0,0 +1,168 @@
+package main
+
+import "strings"
+
+// FormatRules defines an MTG format entirely from configuration. Besides
+// these limits, a configured format uses the LegalSets and BannedCards
+// entries under its key.
+type FormatRules struct {
+	// Name is the display name used in messages.
+	Name string `json:"name"`
+	// MinSize is the minimum main deck size.
+	MinSize int `json:"minSize"`
+	// MaxCopies is the copy limit per card.
+	MaxCopies int `json:"maxCopies"`
+	// SideboardMax is the largest sideboard allowed, or 0 for no limit.
+	SideboardMax int `json:"sideboardMax,omitempty"`
+	// Restricted lists cards limited to a single copy across the main deck
+	// and sideboard.
+	Restricted []string `json:"restricted,omitempty"`
+	// RarityLimits caps copies per card by rarity, keyed by lower-case
+	// rarity, in place of MaxCopies; 0 means unlimited. Rarities without an
+	// entry, and cards without rarity data, use MaxCopies.
+	RarityLimits map[string]int `json:"rarityLimits,omitempty"`
+	// PlaneswalkerUniqueness enables the pre-2018 planeswalker uniqueness
+	// advisory for retro formats.
+	PlaneswalkerUniqueness bool `json:"planeswalkerUniqueness,omitempty"`
+	// Rebalanced counts Arena's rebalanced "A-" cards as their base card
+	// for copy limits.
+	Rebalanced bool `json:"rebalanced,omitempty"`
+}
+
+func defaultFormats() map[string]FormatRules {
+	return map[string]FormatRules{
+		"premodern": {Name: "Premodern", MinSize: 60, MaxCopies: 4, SideboardMax: 15},
+		"oldschool": {
+			Name: "Old School", MinSize: 60, MaxCopies: 4, SideboardMax: 15,
+			Restricted: []string{
+				"Ancestral Recall", "Balance", "Black Lotus", "Braingeyser",
+				"Channel", "Chaos Orb", "Demonic Tutor", "Library of Alexandria",
+				"Mana Drain", "Mind Twist", "Mox Emerald", "Mox Jet", "Mox Pearl",
+				"Mox Ruby", "Mox Sapphire", "Recall", "Regrowth", "Sol Ring",
+				"Time Walk", "Timetwister", "Wheel of Fortune",
+			},
+		},
+		// Arena's digital formats. Their banlists and legal sets change with
+		// each release, so they come from bannedCards and legalSets.
+		"alchemy":  {Name: "Alchemy", MinSize: 60, MaxCopies: 4, SideboardMax: 15, Rebalanced: true},
+		"timeless": {Name: "Timeless", MinSize: 60, MaxCopies: 4, SideboardMax: 15},
+		"explorer": {Name: "Explorer", MinSize: 60, MaxCopies: 4, SideboardMax: 15},
+	}
+}
+
+// checkConfiguredFormat validates a deck against a format from
+// rules.Formats: deck size, copy and rarity limits, sideboard size, set
+// legality, the restricted list and, when enabled, planeswalker
+// uniqueness. Banned cards are checked for every format by
+// checkBannedCards.
+func checkConfiguredFormat(deck *Deck, format FormatRules, overrides deckOverrides, totalCards int, active *Rules, result *ValidationResult) {
+	checkMinSize(format.Name, overrides.deckSize(format.MinSize), totalCards, result)
+	limited := deck
+	if format.Rebalanced {
+		limited = withBaseNames(deck)
+	}
+	checkRarityLimits(limited, format, overrides.maxCopies(format.MaxCopies), result)
+	if format.SideboardMax > 0 {
+		checkSideboardSize(format.Name, format.SideboardMax, deck, result)
+	}
+	checkSetLegality(deck, active, result)
+	if format.PlaneswalkerUniqueness {
+		checkPlaneswalkerUniqueness(deck, result)
+	}
+
+	restricted := map[string]bool{}
+	for _, name := range format.Restricted {
+		restricted[normalizeName(deck.Game, name)] = true
+	}
+	copies := map[string]int{}
+	var order []DeckCard
+	for _, card := range append(append([]DeckCard{}, deck.Cards...), deck.Sideboard...) {
+		key := cardKey(deck.Game, card)
+		if !restricted[key] {
+			continue
+		}
+		if _, seen := copies[key]; !seen {
+			order = append(order, card)
+		}
+		copies[key] += card.Count
+	}
+	for _, card := range order {
+		if n := copies[cardKey(deck.Game, card)]; n > 1 {
+			result.addError(CodeRestrictedCard, displayName(card), displayName(card), format.Name, n)
+		}
+	}
+}
+
+// withBaseNames returns a copy of deck with rebalanced card names replaced
+// by their base card's, so the two are counted together.
+func withBaseNames(deck *Deck) *Deck {
+	out := *deck
+	rename := func(cards []DeckCard) []DeckCard {
+		if cards == nil {
+			return nil
+		}
+		renamed := make([]DeckCard, len(cards))
+		for i, card := range cards {
+			card.Name = arenaRebalancedName(card.Name)
+			if len(card.Faces) > 0 {
+				card.Faces = append([]string{arenaRebalancedName(card.Faces[0])}, card.Faces[1:]...)
+			}
+			renamed[i] = card
+		}
+		return renamed
+	}
+	out.Cards = rename(deck.Cards)
+	out.Sideboard = rename(deck.Sideboard)
+	if deck.Companion != nil {
+		companion := *deck.Companion
+		companion.Name = arenaRebalancedName(companion.Name)
+		out.Companion = &companion
+	}
+	return &out
+}
+
+// checkPlaneswalkerUniqueness warns for every planeswalker with more than
+// one copy in the main deck, since under the pre-2018 uniqueness rule a
+// second copy in play would put the first into the graveyard. Cards
+// without type data aren't checked.
+func checkPlaneswalkerUniqueness(deck *Deck, result *ValidationResult) {
+	copies := map[string]int{}
+	var order []DeckCard
+	for _, card := range deck.Cards {
+		if !hasType(card, "planeswalker") {
+			continue
+		}
+		key := faceKey(deck.Game, card)
+		if _, seen := copies[key]; !seen {
+			order = append(order, card)
+		}
+		copies[key] += card.Count
+	}
+	for _, card := range order {
+		if n := copies[faceKey(deck.Game, card)]; n > 1 {
+			result.addWarning(CodePlaneswalkerUnique, displayName(card), displayName(card), n)
+		}
+	}
+}
+
+// checkRarityLimits applies the copy limit, using format.RarityLimits for
+// cards whose rarity has an entry and limit for the rest. Cards without
+// rarity data get a MISSING_RARITY warning when the format has rarity
+// limits.
+func checkRarityLimits(deck *Deck, format FormatRules, limit int, result *ValidationResult) {
+	if len(format.RarityLimits) == 0 {
+		checkCopyLimit(deck, limit, result)
+		return
+	}
+	checkCopyLimits(deck, func(card DeckCard) (int, string) {
+		if card.Rarity == "" {
+			result.addWarning(CodeMissingRarity, displayName(card), displayName(card), limit)
+			return limit, ""
+		}
+		rarity := strings.ToLower(strings.TrimSpace(card.Rarity))
+		if max, ok := format.RarityLimits[rarity]; ok {
+			return max, rarity
+		}
+		return limit, ""
+	}, result)
+}
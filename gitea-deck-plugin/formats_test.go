@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestPlaneswalkerUniqueness(t *testing.T) {
+	saved := rules
+	defer func() { rules = saved }()
+	rules = defaultRules()
+	oldRules := rules.Formats["premodern"]
+	oldRules.Name = "Old Rules"
+	oldRules.PlaneswalkerUniqueness = true
+	rules.Formats["oldrules"] = oldRules
+
+	tests := []struct {
+		format string
+		cards  []DeckCard
+		want   bool // whether Garruk gets PLANESWALKER_UNIQUENESS
+	}{
+		{"oldrules", []DeckCard{{Name: "Garruk Wildspeaker", Count: 2, Type: "Legendary Planeswalker — Garruk"}}, true},
+		{"oldrules", []DeckCard{
+			{Name: "Garruk Wildspeaker", Count: 1, Type: "Legendary Planeswalker — Garruk"},
+			{Name: "garruk wildspeaker", Count: 1, Type: "Legendary Planeswalker — Garruk"},
+		}, true},
+		{"oldrules", []DeckCard{{Name: "Garruk Wildspeaker", Count: 1, Type: "Legendary Planeswalker — Garruk"}}, false},
+		{"oldrules", []DeckCard{{Name: "Garruk Wildspeaker", Count: 2}}, false},
+		{"premodern", []DeckCard{{Name: "Garruk Wildspeaker", Count: 2, Type: "Legendary Planeswalker — Garruk"}}, false},
+	}
+	for _, tt := range tests {
+		deck := &Deck{Game: "mtg", Format: tt.format, Cards: append(tt.cards, DeckCard{Name: "Forest", Count: 58})}
+		result := validateDeck(deck, ValidateOptions{SkipAdvisory: true})
+		if got := hasIssue(result, CodePlaneswalkerUnique, "Garruk Wildspeaker"); got != tt.want {
+			t.Errorf("%s with %v: warned = %v, want %v (warnings %v)", tt.format, tt.cards, got, tt.want, result.Warnings)
+		}
+	}
+}
@@ -5,4 +5,5 @@ go 1.21
 require (
 	code.gitea.io/gitea v1.21.0
 	github.com/go-chi/chi/v5 v5.0.10
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 )
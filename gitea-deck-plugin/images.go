@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxImageSize is the largest card image the proxy will download.
+const maxImageSize = 10 << 20
+
+// errImageNotFound is returned when the provider has no image for a card.
+var errImageNotFound = errors.New("image not found")
+
+// cardImageProxy fetches card images from the URLs in the card database and
+// caches them on disk, so the viewer never hotlinks the provider. At most
+// cap(slots) fetches run at once, and fetches start at least interval apart.
+// A 429 from the provider pauses fetching for its Retry-After.
+type cardImageProxy struct {
+	dir      string
+	ttl      time.Duration
+	client   *http.Client
+	slots    chan struct{}
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// imageProxy serves /api/cards/image. It is set once at startup.
+var imageProxy *cardImageProxy
+
+func newCardImageProxy(dir string, ttl time.Duration, concurrency int, interval time.Duration) *cardImageProxy {
+	return &cardImageProxy{
+		dir:      dir,
+		ttl:      ttl,
+		client:   &http.Client{Timeout: 15 * time.Second},
+		slots:    make(chan struct{}, concurrency),
+		interval: interval,
+	}
+}
+
+// path is the cache file for a card's image.
+func (p *cardImageProxy) path(game, id string) string {
+	sum := sha256.Sum256([]byte(game + "\x00" + id))
+	return filepath.Join(p.dir, hex.EncodeToString(sum[:]))
+}
+
+// cached returns the cached image for a card, if one is still fresh.
+func (p *cardImageProxy) cached(path string) ([]byte, bool) {
+	info, err := os.Stat(path)
+	if err != nil || (p.ttl > 0 && time.Since(info.ModTime()) > p.ttl) {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	return data, err == nil
+}
+
+// wait blocks until the rate limit allows another fetch to start. It fails
+// with errProviderBusy, without taking a turn, when that is more than max
+// away, and with ctx's error if ctx is done first.
+func (p *cardImageProxy) wait(ctx context.Context, max time.Duration) error {
+	p.mu.Lock()
+	now := time.Now()
+	start := p.next
+	if start.Before(now) {
+		start = now
+	}
+	if start.Sub(now) > max {
+		p.mu.Unlock()
+		return errProviderBusy
+	}
+	p.next = start.Add(p.interval)
+	p.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(start))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// backoff delays every later fetch by d.
+func (p *cardImageProxy) backoff(d time.Duration) {
+	p.mu.Lock()
+	if until := time.Now().Add(d); until.After(p.next) {
+		p.next = until
+	}
+	p.mu.Unlock()
+}
+
+// image returns a card's image, from the cache or the provider. Waiting
+// for one of p's fetch slots, for the rate limit and for a providerLimit
+// slot each fail with errProviderBusy after providerLimit.wait, and stop
+// when ctx is done.
+func (p *cardImageProxy) image(ctx context.Context, game, id, imageURL string) ([]byte, error) {
+	path := p.path(game, id)
+	if data, ok := p.cached(path); ok {
+		return data, nil
+	}
+
+	timer := time.NewTimer(providerLimit.wait)
+	select {
+	case p.slots <- struct{}{}:
+		timer.Stop()
+	case <-timer.C:
+		return nil, errProviderBusy
+	case <-ctx.Done():
+		timer.Stop()
+		return nil, ctx.Err()
+	}
+	defer func() { <-p.slots }()
+	// Another request may have fetched the image while this one waited.
+	if data, ok := p.cached(path); ok {
+		return data, nil
+	}
+	if err := p.wait(ctx, providerLimit.wait); err != nil {
+		return nil, err
+	}
+	release, err := providerLimit.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetching image: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching image: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, errImageNotFound
+	case resp.StatusCode == http.StatusTooManyRequests:
+		retry := time.Minute
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			retry = time.Duration(secs) * time.Second
+		}
+		p.backoff(retry)
+		return nil, fmt.Errorf("image provider rate limited, retrying in %s", retry)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("image provider returned %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading image: %w", err)
+	}
+	if len(data) > maxImageSize {
+		return nil, errors.New("image too large")
+	}
+	if !strings.HasPrefix(http.DetectContentType(data), "image/") {
+		return nil, errors.New("image provider returned a non-image response")
+	}
+
+	// A failed cache write only costs a refetch, so it isn't reported.
+	p.store(path, data)
+	return data, nil
+}
+
+// store writes a cache file through a uniquely named temporary file in the
+// cache directory, so concurrent fetches of one card never write to the
+// same file and readers never see a partial image.
+func (p *cardImageProxy) store(path string, data []byte) error {
+	if err := os.MkdirAll(p.dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(p.dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	return err
+}
+
+func cardImageHandler(w http.ResponseWriter, r *http.Request) {
+	if cardDB == nil {
+		http.Error(w, "card database not configured", http.StatusServiceUnavailable)
+		return
+	}
+	q := r.URL.Query()
+	game := strings.ToLower(q.Get("game"))
+	id := q.Get("id")
+	if game == "" || id == "" {
+		http.Error(w, "game and id parameters required", http.StatusBadRequest)
+		return
+	}
+
+	card, ok := cardDB.Lookup(game, id)
+	if !ok {
+		http.Error(w, "card not found", http.StatusNotFound)
+		return
+	}
+	u, err := url.Parse(card.ImageURL)
+	if card.ImageURL == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		http.Error(w, "card has no image", http.StatusNotFound)
+		return
+	}
+
+	data, err := imageProxy.image(r.Context(), game, id, card.ImageURL)
+	if errors.Is(err, errImageNotFound) {
+		http.Error(w, "image not found", http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, errProviderBusy) {
+		w.Header().Set("Retry-After", retryAfter(providerLimit.wait))
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	if imageProxy.ttl > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(imageProxy.ttl.Seconds())))
+	}
+	w.Write(data)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestImageCacheStore(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes int
+	}{
+		{"one fetch", 1},
+		{"concurrent fetches of one card", 16},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		p := newCardImageProxy(dir+"/images", time.Hour, 1, 0)
+		path := p.path("mtg", "abc")
+
+		// Each write is a different image, so a mix of two shows up as a
+		// corrupt file.
+		images := make([][]byte, tt.writes)
+		var wg sync.WaitGroup
+		for i := range images {
+			images[i] = bytes.Repeat([]byte{byte('a' + i)}, 64<<10)
+			wg.Add(1)
+			go func(data []byte) {
+				defer wg.Done()
+				if err := p.store(path, data); err != nil {
+					t.Errorf("%s: %v", tt.name, err)
+				}
+			}(images[i])
+		}
+		wg.Wait()
+
+		got, ok := p.cached(path)
+		if !ok {
+			t.Fatalf("%s: nothing cached", tt.name)
+		}
+		whole := false
+		for _, data := range images {
+			whole = whole || bytes.Equal(got, data)
+		}
+		if !whole {
+			t.Errorf("%s: cached image is a mix of writes", tt.name)
+		}
+		entries, _ := os.ReadDir(dir + "/images")
+		if len(entries) != 1 {
+			t.Errorf("%s: %d files in the cache, want 1", tt.name, len(entries))
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// TypeRule assigns Type to cards whose name matches Pattern, a regular
+// expression matched case-insensitively anywhere in the name.
+type TypeRule struct {
+	Pattern string `json:"pattern"`
+	Type    string `json:"type"`
+}
+
+// checkTypeRules reports the first rule without a type or with an
+// invalid pattern.
+func checkTypeRules(typeRules []TypeRule) error {
+	for i, rule := range typeRules {
+		if rule.Type == "" {
+			return fmt.Errorf("rule %d: type required", i)
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("rule %d: invalid pattern: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// inferTypes returns a copy of deck with Type filled in for every card
+// that has none, from the first rule whose pattern matches its name. Cards
+// that already have a type keep it, and cards no rule matches are left
+// untyped. Rules without a type or with an invalid pattern are skipped.
+func inferTypes(deck *Deck, typeRules []TypeRule) *Deck {
+	var valid []TypeRule
+	var patterns []*regexp.Regexp
+	for _, rule := range typeRules {
+		if re, err := regexp.Compile("(?i)" + rule.Pattern); err == nil && rule.Type != "" {
+			valid = append(valid, rule)
+			patterns = append(patterns, re)
+		}
+	}
+	infer := func(card DeckCard) DeckCard {
+		if card.Type != "" || card.Name == "" {
+			return card
+		}
+		for i, re := range patterns {
+			if re.MatchString(card.Name) {
+				card.Type = valid[i].Type
+				break
+			}
+		}
+		return card
+	}
+	zone := func(cards []DeckCard) []DeckCard {
+		if cards == nil {
+			return nil
+		}
+		out := make([]DeckCard, len(cards))
+		for i, card := range cards {
+			out[i] = infer(card)
+		}
+		return out
+	}
+
+	out := *deck
+	out.Cards = zone(deck.Cards)
+	out.Sideboard = zone(deck.Sideboard)
+	out.Maybeboard = zone(deck.Maybeboard)
+	out.Commanders = zone(deck.Commanders)
+	out.Battlefields = zone(deck.Battlefields)
+	out.Runes = zone(deck.Runes)
+	for _, card := range []**DeckCard{&out.Commander, &out.Companion, &out.Oathbreaker, &out.SignatureSpell, &out.Legend, &out.Battlefield} {
+		if *card != nil {
+			c := infer(**card)
+			*card = &c
+		}
+	}
+	return &out
+}
+
+// untypedCards lists the names of main deck and sideboard cards that still
+// have no type.
+func untypedCards(deck *Deck) []string {
+	names := []string{}
+	for _, card := range append(append([]DeckCard{}, deck.Cards...), deck.Sideboard...) {
+		if card.Type == "" {
+			names = append(names, displayName(card))
+		}
+	}
+	return names
+}
+
+type inferTypesRequest struct {
+	Deck  Deck       `json:"deck"`
+	Rules []TypeRule `json:"rules"`
+}
+
+type inferTypesResponse struct {
+	Deck    *Deck    `json:"deck"`
+	Untyped []string `json:"untyped"`
+}
+
+func inferTypesHandler(w http.ResponseWriter, r *http.Request) {
+	var req inferTypesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(req.Rules) == 0 {
+		http.Error(w, "rules required", http.StatusBadRequest)
+		return
+	}
+	if err := checkTypeRules(req.Rules); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	deck := inferTypes(&req.Deck, req.Rules)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(inferTypesResponse{Deck: deck, Untyped: untypedCards(deck)})
+}
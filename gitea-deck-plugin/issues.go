@@ -0,0 +1,180 @@
+package main
+
+import "sort"
+
+// Issue is a single validation finding with a stable machine-readable code.
+type Issue struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Card     string `json:"card,omitempty"`
+	// Rule names the rule behind the issue, where the explain endpoint
+	// knows it.
+	Rule string `json:"rule,omitempty"`
+
+	// Escalated marks an error that was a warning before
+	// warnings-as-errors raised it.
+	Escalated bool `json:"escalated,omitempty"`
+
+	// args are the values the message was formatted from, kept so later
+	// passes can act on an issue without parsing its message.
+	args []interface{}
+}
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Issue codes. These are part of the API and must not change once released.
+const (
+	CodeDeckSizeMismatch      = "DECK_SIZE_MISMATCH"
+	CodeDeckSizeTooSmall      = "DECK_SIZE_TOO_SMALL"
+	CodeCopyLimitExceeded     = "COPY_LIMIT_EXCEEDED"
+	CodeSetNotLegal           = "SET_NOT_LEGAL"
+	CodeSetUnknown            = "SET_UNKNOWN"
+	CodeMissingLegend         = "MISSING_LEGEND"
+	CodeMissingBattlefield    = "MISSING_BATTLEFIELD"
+	CodeBattlefieldCount      = "BATTLEFIELD_COUNT"
+	CodeDuplicateBattlefield  = "DUPLICATE_BATTLEFIELD"
+	CodeRuneCount             = "RUNE_COUNT"
+	CodeConflictingGameFields = "CONFLICTING_GAME_FIELDS"
+	CodeForeignZoneIgnored    = "FOREIGN_ZONE_IGNORED"
+	CodeSideboardNotAllowed   = "SIDEBOARD_NOT_ALLOWED"
+	CodeSideboardMerged       = "SIDEBOARD_MERGED"
+	CodeCommandersIgnored     = "COMMANDERS_IGNORED"
+	CodeCommandersNotAllowed  = "COMMANDERS_NOT_ALLOWED"
+	CodeCompanionNotAllowed   = "COMPANION_NOT_ALLOWED"
+	CodeCompanionUncheckable  = "COMPANION_UNCHECKABLE"
+	CodeCompanionViolation    = "COMPANION_VIOLATION"
+	CodeCompanionUnchecked    = "COMPANION_UNCHECKED_CARDS"
+	CodeNoWinCondition        = "NO_WIN_CONDITION"
+	CodeTooManyWarnings       = "TOO_MANY_WARNINGS"
+	CodeOverrideInEffect      = "OVERRIDE_IN_EFFECT"
+	CodeOverrideUnknown       = "OVERRIDE_UNKNOWN"
+	CodeOverrideInvalid       = "OVERRIDE_INVALID"
+	CodeUnknownCardID         = "UNKNOWN_CARD_ID"
+	CodeUnknownCardName       = "UNKNOWN_CARD_NAME"
+	CodePointsCapExceeded     = "POINTS_CAP_EXCEEDED"
+	CodePoolCardMissing       = "POOL_CARD_MISSING"
+	CodePoolCopiesExceeded    = "POOL_COPIES_EXCEEDED"
+	CodeUnknownFormat         = "UNKNOWN_FORMAT"
+	CodeFormatAliased         = "FORMAT_ALIASED"
+	CodeAuthorMismatch        = "AUTHOR_MISMATCH"
+	CodeLandCount             = "LAND_COUNT"
+	CodeNotInCube             = "NOT_IN_CUBE"
+	CodeBannedCard            = "BANNED_CARD"
+	CodeSideboardTooLarge     = "SIDEBOARD_TOO_LARGE"
+	CodeMissingCardID         = "MISSING_CARD_ID"
+	CodeTooManyCommanders     = "TOO_MANY_COMMANDERS"
+	CodeIncompatiblePair      = "INCOMPATIBLE_PAIR"
+	CodeMissingDeckName       = "MISSING_DECK_NAME"
+	CodeDeckNameTooLong       = "DECK_NAME_TOO_LONG"
+	CodeDeckNameInvalid       = "DECK_NAME_INVALID"
+	CodeUnknownBracket        = "UNKNOWN_BRACKET"
+	CodeBracketCardBanned     = "BRACKET_CARD_BANNED"
+	CodeGameChangerLimit      = "GAME_CHANGER_LIMIT"
+	CodeSingletonDuplicates   = "SINGLETON_DUPLICATES"
+	CodeRestrictedCard        = "RESTRICTED_CARD"
+	CodeColorIdentity         = "COLOR_IDENTITY"
+	CodeOathbreakerCount      = "OATHBREAKER_COUNT"
+	CodeSignatureSpellCount   = "SIGNATURE_SPELL_COUNT"
+	CodeNotPlaneswalker       = "NOT_A_PLANESWALKER"
+	CodeNotInstantOrSorcery   = "NOT_INSTANT_OR_SORCERY"
+	CodeSignatureIdentity     = "SIGNATURE_IDENTITY"
+	CodeSideboardBannedCard   = "SIDEBOARD_BANNED_CARD"
+	CodeSideboardCopyLimit    = "SIDEBOARD_COPY_LIMIT"
+	CodeUnknownGame           = "UNKNOWN_GAME"
+	CodeGameNotAllowed        = "GAME_NOT_ALLOWED"
+	CodeTagNotAllowed         = "TAG_NOT_ALLOWED"
+	CodeMissingGame           = "MISSING_GAME"
+	CodeInvalidGame           = "INVALID_GAME"
+	CodeEmptyDeck             = "EMPTY_DECK"
+	CodeUnidentifiedCard      = "UNIDENTIFIED_CARD"
+	CodeInvalidCount          = "INVALID_COUNT"
+	CodeInvalidTimestamp      = "INVALID_TIMESTAMP"
+	CodeHeaderOverride        = "HEADER_OVERRIDE"
+	CodeHeaderOverrideInvalid = "HEADER_OVERRIDE_INVALID"
+	CodeResourceCount         = "RESOURCE_COUNT"
+	CodeUnknownCardSuggest    = "UNKNOWN_CARD_SUGGESTION"
+	CodeRarityLimitExceeded   = "RARITY_LIMIT_EXCEEDED"
+	CodeMissingRarity         = "MISSING_RARITY"
+	CodeThemeConsistency      = "THEME_CONSISTENCY"
+	CodePlaneswalkerUnique    = "PLANESWALKER_UNIQUENESS"
+	CodePlaygroupBanned       = "PLAYGROUP_BANNED"
+	CodeWrongGameCard         = "WRONG_GAME_CARD"
+	CodeBannedCombo           = "BANNED_COMBO"
+)
+
+// addError records an error, marking the deck invalid. The message is
+// rendered in English from the code's template; handlers re-render it in the
+// client's locale. It is also appended to the legacy Errors list.
+func (r *ValidationResult) addError(code, card string, args ...interface{}) {
+	msg := renderMessage(defaultLocale, code, args)
+	r.Valid = false
+	r.Errors = append(r.Errors, msg)
+	r.Issues = append(r.Issues, Issue{Code: code, Severity: SeverityError, Message: msg, Card: card, args: args})
+}
+
+// addWarning records a warning. Like addError, the message comes from the
+// code's template and is also appended to the legacy Warnings list.
+func (r *ValidationResult) addWarning(code, card string, args ...interface{}) {
+	msg := renderMessage(defaultLocale, code, args)
+	r.Warnings = append(r.Warnings, msg)
+	r.Issues = append(r.Issues, Issue{Code: code, Severity: SeverityWarning, Message: msg, Card: card, args: args})
+}
+
+// escalateWarnings turns every warning into an error, marked Escalated,
+// and rebuilds the legacy lists from the issues, for warnings-as-errors.
+func (r *ValidationResult) escalateWarnings() {
+	r.Errors = []string{}
+	r.Warnings = []string{}
+	for i := range r.Issues {
+		issue := &r.Issues[i]
+		if issue.Severity == SeverityWarning {
+			issue.Severity = SeverityError
+			issue.Escalated = true
+		}
+		if issue.Severity == SeverityError {
+			r.Errors = append(r.Errors, issue.Message)
+			r.Valid = false
+		}
+	}
+}
+
+// addInfo records an informational note. Notes only appear in Issues; they
+// never affect validity and have no legacy list.
+func (r *ValidationResult) addInfo(code, card string, args ...interface{}) {
+	msg := renderMessage(defaultLocale, code, args)
+	r.Issues = append(r.Issues, Issue{Code: code, Severity: SeverityInfo, Message: msg, Card: card, args: args})
+}
+
+// groupIssuesByCard groups issues by the card they are about, with issues
+// about the deck as a whole under "deck". Each group lists errors, then
+// warnings, then info, keeping the original order within a severity.
+func groupIssuesByCard(issues []Issue) map[string][]Issue {
+	rank := map[string]int{SeverityError: 0, SeverityWarning: 1, SeverityInfo: 2}
+	groups := map[string][]Issue{}
+	for _, issue := range issues {
+		key := issue.Card
+		if key == "" {
+			key = "deck"
+		}
+		groups[key] = append(groups[key], issue)
+	}
+	for _, group := range groups {
+		sort.SliceStable(group, func(i, j int) bool { return rank[group[i].Severity] < rank[group[j].Severity] })
+	}
+	return groups
+}
+
+// merge appends every issue from other to r.
+func (r *ValidationResult) merge(other ValidationResult) {
+	if !other.Valid {
+		r.Valid = false
+	}
+	r.Errors = append(r.Errors, other.Errors...)
+	r.Warnings = append(r.Warnings, other.Warnings...)
+	r.Issues = append(r.Issues, other.Issues...)
+}
@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestWarningsAsErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		deck      *Deck
+		escalate  bool
+		wantValid bool
+		errors    int
+		warnings  int
+	}{
+		{"warnings only", &Deck{Game: "mtg", Format: "modern", Cards: []DeckCard{{Name: "Island", Count: 60}}}, false, true, 0, 2},
+		{"warnings only, escalated", &Deck{Game: "mtg", Format: "modern", Cards: []DeckCard{{Name: "Island", Count: 60}}}, true, false, 2, 0},
+		{"errors and warnings, escalated", &Deck{Game: "mtg", Format: "modern", Cards: []DeckCard{{Name: "Island", Count: 50}}}, true, false, 3, 0},
+		{"clean deck, escalated", &Deck{Game: "mtg", Format: "modern", Name: "Mono Blue", Cards: []DeckCard{
+			{Name: "Island", Count: 20}, {Name: "Delver of Secrets", Count: 4, Type: "Creature"},
+			{Name: "Opt", Count: 4}, {Name: "Consider", Count: 4}, {Name: "Counterspell", Count: 4},
+			{Name: "Brazen Borrower", Count: 4}, {Name: "Spell Pierce", Count: 4}, {Name: "Thing in the Ice", Count: 4},
+			{Name: "Snapcaster Mage", Count: 4}, {Name: "Archmage's Charm", Count: 4}, {Name: "Cryptic Command", Count: 4},
+		}}, true, true, 0, 0},
+	}
+	for _, tt := range tests {
+		result := validateDeck(tt.deck, ValidateOptions{WarningsAsErrors: tt.escalate})
+		// Handlers localize before responding, which rebuilds the lists
+		// from the issues.
+		result.localize(defaultLocale)
+		if result.Valid != tt.wantValid || len(result.Errors) != tt.errors || len(result.Warnings) != tt.warnings {
+			t.Errorf("%s: valid %v with %d errors and %d warnings, want %v with %d and %d (%v, %v)",
+				tt.name, result.Valid, len(result.Errors), len(result.Warnings), tt.wantValid, tt.errors, tt.warnings, result.Errors, result.Warnings)
+		}
+		errorIssues := 0
+		for _, issue := range result.Issues {
+			if issue.Severity == SeverityWarning && tt.escalate {
+				t.Errorf("%s: %s is still a warning", tt.name, issue.Code)
+			}
+			if issue.Escalated != (tt.escalate && issue.Code != CodeDeckSizeTooSmall) {
+				t.Errorf("%s: %s escalated = %v", tt.name, issue.Code, issue.Escalated)
+			}
+			if issue.Severity == SeverityError {
+				errorIssues++
+			}
+		}
+		if errorIssues != len(result.Errors) {
+			t.Errorf("%s: %d error issues but %d errors", tt.name, errorIssues, len(result.Errors))
+		}
+	}
+}
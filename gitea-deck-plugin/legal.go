@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// LegalizationPlan is the smallest set of numeric adjustments that brings a
+// deck within its size and copy limits. CardsToAdd and CardsToCut are the
+// unspecified main deck cards still to add or cut once the steps are done.
+// Unresolved lists the errors the plan doesn't cover, such as banned cards;
+// Achievable is true when there are none.
+type LegalizationPlan struct {
+	Steps      []DeckEdit `json:"steps"`
+	CardsToAdd int        `json:"cardsToAdd"`
+	CardsToCut int        `json:"cardsToCut"`
+	Achievable bool       `json:"achievable"`
+	Unresolved []Issue    `json:"unresolved"`
+}
+
+// pathToLegal plans the adjustments for result, the deck's validation under
+// opts. Copy counts are reduced to their limits first, then sideboard
+// copies of cards still over the limit across zones; the deck size needed
+// is worked out with those cuts made, so cards cut for copy limits aren't
+// cut twice.
+func pathToLegal(deck *Deck, result ValidationResult, opts ValidateOptions) LegalizationPlan {
+	plan := LegalizationPlan{Steps: []DeckEdit{}, Unresolved: []Issue{}}
+	reduce := func(issue Issue, zone string, current, to int) {
+		var cut int
+		deck, cut = withCopiesCut(deck, zone, issueCard(deck, issue.Card), current-to)
+		if cut == 0 {
+			return
+		}
+		to = current - cut
+		edit := DeckEdit{
+			Code:        issue.Code,
+			Op:          "set-count",
+			Zone:        zone,
+			Card:        issue.Card,
+			From:        current,
+			To:          to,
+			Fixable:     true,
+			Description: fmt.Sprintf("Reduce '%s' to %d", issue.Card, to),
+		}
+		if to == 0 {
+			edit.Op = "remove"
+			edit.Description = fmt.Sprintf("Remove every copy of '%s' from %s", issue.Card, zone)
+		}
+		plan.Steps = append(plan.Steps, edit)
+	}
+
+	for _, issue := range result.Issues {
+		switch issue.Code {
+		case CodeCopyLimitExceeded:
+			// args: name, limit, current
+			reduce(issue, "cards", issue.args[2].(int), issue.args[1].(int))
+		case CodeRarityLimitExceeded:
+			// args: name, limit, rarity, current
+			reduce(issue, "cards", issue.args[3].(int), issue.args[1].(int))
+		}
+	}
+
+	result = validateDeck(deck, opts)
+	for _, issue := range result.Issues {
+		if issue.Code != CodeSideboardCopyLimit {
+			continue
+		}
+		// args: name, limit, total across zones
+		over := issue.args[2].(int) - issue.args[1].(int)
+		if side := copiesIn(deck, deck.Sideboard, issueCard(deck, issue.Card)); side > 0 {
+			if over > side {
+				over = side
+			}
+			reduce(issue, "sideboard", side, side-over)
+		}
+	}
+
+	result = validateDeck(deck, opts)
+	for _, issue := range result.Issues {
+		if issue.Severity != SeverityError {
+			continue
+		}
+		switch issue.Code {
+		case CodeDeckSizeTooSmall, CodeDeckSizeMismatch:
+			// args: format name, required size, current
+			required, current := issue.args[1].(int), issue.args[2].(int)
+			edit := DeckEdit{Code: issue.Code, Zone: "cards", From: current, To: required}
+			if current < required {
+				plan.CardsToAdd = required - current
+				edit.Op = "add"
+				edit.Description = fmt.Sprintf("Add %d more cards", plan.CardsToAdd)
+			} else {
+				plan.CardsToCut = current - required
+				edit.Op = "cut"
+				edit.Description = fmt.Sprintf("Cut %d cards", plan.CardsToCut)
+			}
+			plan.Steps = append(plan.Steps, edit)
+		case CodeSideboardTooLarge:
+			// args: format name, maximum, current
+			max, current := issue.args[1].(int), issue.args[2].(int)
+			plan.Steps = append(plan.Steps, DeckEdit{
+				Code:        issue.Code,
+				Op:          "cut",
+				Zone:        "sideboard",
+				From:        current,
+				To:          max,
+				Description: fmt.Sprintf("Cut %d sideboard cards", current-max),
+			})
+		default:
+			plan.Unresolved = append(plan.Unresolved, issue)
+		}
+	}
+	plan.Achievable = len(plan.Unresolved) == 0
+	return plan
+}
+
+// limitKey is the key the copy limit groups a card of deck under: its
+// front face, as the base card for formats that count rebalanced cards as
+// their base card.
+func limitKey(deck *Deck, card DeckCard) string {
+	if format, ok := rules.Formats[deck.Format]; ok && format.Rebalanced {
+		card = withBaseNames(&Deck{Cards: []DeckCard{card}}).Cards[0]
+	}
+	return faceKey(deck.Game, card)
+}
+
+// issueCard returns the deck entry an issue names, by its display name,
+// so cards known only by ID are matched by ID rather than as a name. Names
+// with no entry are returned as a name-only card.
+func issueCard(deck *Deck, name string) DeckCard {
+	for _, zone := range [][]DeckCard{deck.Cards, deck.Sideboard, commandersOf(deck)} {
+		for _, card := range zone {
+			if displayName(card) == name {
+				return card
+			}
+		}
+	}
+	return DeckCard{Name: name}
+}
+
+// copiesIn counts the copies of target in cards, matching them like the
+// copy limit does.
+func copiesIn(deck *Deck, cards []DeckCard, target DeckCard) int {
+	key := limitKey(deck, target)
+	n := 0
+	for _, card := range cards {
+		if limitKey(deck, card) == key {
+			n += card.Count
+		}
+	}
+	return n
+}
+
+// withCopiesCut returns a copy of deck with up to n copies of target
+// removed from zone ("cards" or "sideboard"), taken from the last matching
+// entries first, and how many copies were cut. Entries left with no copies
+// are dropped.
+func withCopiesCut(deck *Deck, zone string, target DeckCard, n int) (*Deck, int) {
+	out := *deck
+	cards := &out.Cards
+	if zone == "sideboard" {
+		cards = &out.Sideboard
+	}
+	key := limitKey(deck, target)
+	kept := append([]DeckCard{}, *cards...)
+	total := 0
+	for i := len(kept) - 1; i >= 0 && n > 0; i-- {
+		if limitKey(deck, kept[i]) != key {
+			continue
+		}
+		cut := kept[i].Count
+		if cut > n {
+			cut = n
+		}
+		kept[i].Count -= cut
+		n -= cut
+		total += cut
+		if kept[i].Count == 0 {
+			kept = append(kept[:i], kept[i+1:]...)
+		}
+	}
+	*cards = kept
+	return &out, total
+}
+
+type toLegalResponse struct {
+	Validation ValidationResult `json:"validation"`
+	Plan       LegalizationPlan `json:"plan"`
+}
+
+func toLegalHandler(w http.ResponseWriter, r *http.Request) {
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	opts := validateOptionsFromRequest(r)
+	validation := validateDeck(deck, opts)
+	plan := pathToLegal(deck, validation, opts)
+	locale := negotiateLocale(r.Header.Get("Accept-Language"))
+	for i := range plan.Unresolved {
+		plan.Unresolved[i].Message = renderMessage(locale, plan.Unresolved[i].Code, plan.Unresolved[i].args)
+	}
+	localizeResult(w, r, &validation)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toLegalResponse{Validation: validation, Plan: plan})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPathToLegal(t *testing.T) {
+	tests := []struct {
+		name       string
+		deck       *Deck
+		steps      []string // "code op zone card from->to" per step
+		toAdd      int
+		toCut      int
+		achievable bool
+	}{
+		{"legal deck",
+			&Deck{Game: "mtg", Format: "modern", Cards: []DeckCard{{Name: "Lightning Bolt", Count: 4}, {Name: "Mountain", Count: 56}}},
+			nil, 0, 0, true},
+		{"over the copy limit",
+			&Deck{Game: "mtg", Format: "modern", Cards: []DeckCard{{Name: "Lightning Bolt", Count: 6}, {Name: "Mountain", Count: 54}}},
+			[]string{
+				"COPY_LIMIT_EXCEEDED set-count cards Lightning Bolt 6->4",
+				"DECK_SIZE_TOO_SMALL add cards  58->60",
+			}, 2, 0, true},
+		{"ID-only card",
+			&Deck{Game: "mtg", Format: "modern", Cards: []DeckCard{{ID: "AbC-1", Count: 6}, {Name: "Mountain", Count: 54}}},
+			[]string{
+				"COPY_LIMIT_EXCEEDED set-count cards AbC-1 6->4",
+				"DECK_SIZE_TOO_SMALL add cards  58->60",
+			}, 2, 0, true},
+		{"entries under two spellings",
+			&Deck{Game: "mtg", Format: "modern", Cards: []DeckCard{
+				{Name: "Lim-Dûl's Vault", Count: 3}, {Name: "Lim-Dul's Vault", Count: 3}, {Name: "Swamp", Count: 56},
+			}},
+			[]string{"COPY_LIMIT_EXCEEDED set-count cards Lim-Dûl's Vault 6->4"}, 0, 0, true},
+		{"sideboard copies over the combined limit",
+			&Deck{Game: "mtg", Format: "modern",
+				Cards:     []DeckCard{{Name: "Lightning Bolt", Count: 3}, {Name: "Mountain", Count: 57}},
+				Sideboard: []DeckCard{{Name: "Lightning Bolt", Count: 2}}},
+			[]string{"SIDEBOARD_COPY_LIMIT set-count sideboard Lightning Bolt 2->1"}, 0, 0, true},
+		{"sideboard copies removed",
+			&Deck{Game: "mtg", Format: "modern",
+				Cards:     []DeckCard{{Name: "Lightning Bolt", Count: 4}, {Name: "Mountain", Count: 56}},
+				Sideboard: []DeckCard{{Name: "Lightning Bolt", Count: 2}}},
+			[]string{"SIDEBOARD_COPY_LIMIT remove sideboard Lightning Bolt 2->0"}, 0, 0, true},
+		{"too many cards",
+			&Deck{Game: "mtg", Format: "commander", Commander: &DeckCard{Name: "Atraxa, Praetors' Voice", Count: 1},
+				Cards: []DeckCard{{Name: "Forest", Count: 105}}},
+			[]string{"DECK_SIZE_MISMATCH cut cards  105->100"}, 0, 5, true},
+		{"banned card",
+			&Deck{Game: "mtg", Format: "legacy", Cards: []DeckCard{{Name: "Black Lotus", Count: 1}, {Name: "Island", Count: 59}}},
+			nil, 0, 0, false},
+	}
+	for _, tt := range tests {
+		opts := ValidateOptions{SkipAdvisory: true}
+		plan := pathToLegal(tt.deck, validateDeck(tt.deck, opts), opts)
+		var steps []string
+		for _, s := range plan.Steps {
+			steps = append(steps, fmt.Sprintf("%s %s %s %s %d->%d", s.Code, s.Op, s.Zone, s.Card, s.From, s.To))
+		}
+		if fmt.Sprint(steps) != fmt.Sprint(tt.steps) {
+			t.Errorf("%s: steps\n  %q\nwant\n  %q", tt.name, steps, tt.steps)
+		}
+		if plan.CardsToAdd != tt.toAdd || plan.CardsToCut != tt.toCut || plan.Achievable != tt.achievable {
+			t.Errorf("%s: add %d, cut %d, achievable %v; want %d, %d, %v (unresolved %v)",
+				tt.name, plan.CardsToAdd, plan.CardsToCut, plan.Achievable, tt.toAdd, tt.toCut, tt.achievable, plan.Unresolved)
+		}
+	}
+}
+
+func TestWithCopiesCut(t *testing.T) {
+	tests := []struct {
+		name    string
+		cards   []DeckCard
+		target  DeckCard
+		n       int
+		want    []DeckCard
+		wantCut int
+	}{
+		{"from the last entry", []DeckCard{{Name: "Opt", Count: 3}, {Name: "opt", Count: 3}}, DeckCard{Name: "Opt"}, 2,
+			[]DeckCard{{Name: "Opt", Count: 3}, {Name: "opt", Count: 1}}, 2},
+		{"drops emptied entries", []DeckCard{{Name: "Opt", Count: 3}, {Name: "Opt", Count: 1}}, DeckCard{Name: "Opt"}, 2,
+			[]DeckCard{{Name: "Opt", Count: 2}}, 2},
+		{"ID-only card", []DeckCard{{ID: "AbC-1", Count: 5}}, DeckCard{ID: "AbC-1"}, 1,
+			[]DeckCard{{ID: "AbC-1", Count: 4}}, 1},
+		{"ID-only card isn't matched as a name", []DeckCard{{ID: "AbC-1", Count: 5}}, DeckCard{Name: "AbC-1"}, 1,
+			[]DeckCard{{ID: "AbC-1", Count: 5}}, 0},
+		{"split card by front face", []DeckCard{{Name: "Fire // Ice", Count: 5}}, DeckCard{Name: "Fire"}, 1,
+			[]DeckCard{{Name: "Fire // Ice", Count: 4}}, 1},
+		{"more than there are", []DeckCard{{Name: "Opt", Count: 2}}, DeckCard{Name: "Opt"}, 5, []DeckCard{}, 2},
+		{"not in the deck", []DeckCard{{Name: "Opt", Count: 2}}, DeckCard{Name: "Ponder"}, 1,
+			[]DeckCard{{Name: "Opt", Count: 2}}, 0},
+	}
+	for _, tt := range tests {
+		deck := &Deck{Game: "mtg", Format: "modern", Cards: tt.cards}
+		out, cut := withCopiesCut(deck, "cards", tt.target, tt.n)
+		if cut != tt.wantCut || fmt.Sprint(out.Cards) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: cut %d leaving %v, want %d leaving %v", tt.name, cut, out.Cards, tt.wantCut, tt.want)
+		}
+		if fmt.Sprint(deck.Cards) != fmt.Sprint(tt.cards) {
+			t.Errorf("%s: the original deck changed", tt.name)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// LintIssue is a style finding that doesn't affect legality, with a
+// suggestion for tidying the deck file.
+type LintIssue struct {
+	Code       string `json:"code"`
+	Severity   string `json:"severity"`
+	Message    string `json:"message"`
+	Card       string `json:"card,omitempty"`
+	Suggestion string `json:"suggestion"`
+}
+
+// Lint codes.
+const (
+	CodeLintDuplicateEntry     = "LINT_DUPLICATE_ENTRY"
+	CodeLintInconsistentName   = "LINT_INCONSISTENT_NAME"
+	CodeLintMissingAuthor      = "LINT_MISSING_AUTHOR"
+	CodeLintMissingCardName    = "LINT_MISSING_CARD_NAME"
+	CodeLintMissingDeckName    = "LINT_MISSING_DECK_NAME"
+	CodeLintMissingDescription = "LINT_MISSING_DESCRIPTION"
+	CodeLintUnsorted           = "LINT_UNSORTED"
+)
+
+// lintDeck reports best-practice issues in a deck file: missing names and
+// metadata, inconsistent spellings, duplicate and unsorted entries.
+func lintDeck(deck *Deck) []LintIssue {
+	issues := []LintIssue{}
+	add := func(code, severity, card, suggestion, format string, args ...interface{}) {
+		issues = append(issues, LintIssue{
+			Code:       code,
+			Severity:   severity,
+			Message:    fmt.Sprintf(format, args...),
+			Card:       card,
+			Suggestion: suggestion,
+		})
+	}
+
+	if strings.TrimSpace(deck.Name) == "" {
+		add(CodeLintMissingDeckName, SeverityWarning, "", "Give the deck a name", "Deck has no name")
+	}
+	if strings.TrimSpace(deck.Metadata.Author) == "" {
+		add(CodeLintMissingAuthor, SeverityWarning, "", "Set metadata.author", "Deck metadata has no author")
+	}
+	if strings.TrimSpace(deck.Metadata.Description) == "" {
+		add(CodeLintMissingDescription, SeverityInfo, "", "Set metadata.description", "Deck metadata has no description")
+	}
+
+	zones := []struct {
+		name  string
+		cards []DeckCard
+	}{
+		{"cards", deck.Cards},
+		{"sideboard", deck.Sideboard},
+		{"maybeboard", deck.Maybeboard},
+	}
+	spellings := map[string]string{}
+	for _, zone := range zones {
+		entries := map[string]bool{}
+		for _, card := range zone.cards {
+			if card.Name == "" {
+				add(CodeLintMissingCardName, SeverityWarning, card.ID, "Add the card's name so the file is readable without a database", "Card %s in %s has no name", card.ID, zone.name)
+				continue
+			}
+			key := cardKey(deck.Game, card)
+			if first, ok := spellings[key]; ok && first != card.Name {
+				add(CodeLintInconsistentName, SeverityWarning, card.Name, fmt.Sprintf("Spell it %q everywhere", first), "%q is also written as %q", first, card.Name)
+			} else if !ok {
+				spellings[key] = card.Name
+			}
+			if entries[key] {
+				add(CodeLintDuplicateEntry, SeverityWarning, card.Name, "Merge the entries into one with the combined count", "%s appears in more than one %s entry", card.Name, zone.name)
+			}
+			entries[key] = true
+		}
+
+		sorted := sort.SliceIsSorted(zone.cards, func(i, j int) bool {
+			return cardKey(deck.Game, zone.cards[i]) < cardKey(deck.Game, zone.cards[j])
+		})
+		if !sorted {
+			add(CodeLintUnsorted, SeverityInfo, "", "Sort entries by name to keep diffs small", "Entries in %s are not sorted by name", zone.name)
+		}
+	}
+	return issues
+}
+
+func lintDeckHandler(w http.ResponseWriter, r *http.Request) {
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lintDeck(deck))
+}
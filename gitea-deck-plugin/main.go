@@ -2,10 +2,16 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -15,6 +21,28 @@ type DeckCard struct {
 	ID    string `json:"id"`
 	Count int    `json:"count"`
 	Name  string `json:"name,omitempty"`
+	Set   string `json:"set,omitempty"`
+
+	// Optional card data used by heuristics when present.
+	CMC    float64  `json:"cmc,omitempty"`
+	Colors []string `json:"colors,omitempty"`
+	Type   string   `json:"type,omitempty"`
+	Land   bool     `json:"land,omitempty"`
+	Rarity string   `json:"rarity,omitempty"`
+
+	// Custom marks proxies and homebrew cards that aren't in any card
+	// database.
+	Custom bool `json:"custom,omitempty"`
+
+	// Faces names each face of a split, flip or double-faced card, front
+	// face first. Names in the "Fire // Ice" form are split into faces when
+	// Faces is empty.
+	Faces []string `json:"faces,omitempty"`
+
+	// PairType is how a commander pairs with a second commander:
+	// "partner", "partner-with:<card name>", "friends-forever",
+	// "doctors-companion", "doctor", "choose-a-background" or "background".
+	PairType string `json:"pairType,omitempty"`
 }
 
 type DeckMetadata struct {
@@ -23,6 +51,14 @@ type DeckMetadata struct {
 	Updated     string   `json:"updated,omitempty"`
 	Description string   `json:"description,omitempty"`
 	Tags        []string `json:"tags,omitempty"`
+
+	// Overrides adjusts validation limits for house-ruled decks. Recognized
+	// keys are "deckSize" and "maxCopies".
+	Overrides map[string]string `json:"overrides,omitempty"`
+
+	// Bracket is the Commander bracket (1-5) the deck is declared for, or 0
+	// if none.
+	Bracket int `json:"bracket,omitempty"`
 }
 
 type Deck struct {
@@ -32,29 +68,242 @@ type Deck struct {
 	Cards     []DeckCard   `json:"cards"`
 	Sideboard []DeckCard   `json:"sideboard,omitempty"`
 	Metadata  DeckMetadata `json:"metadata"`
+
+	// Maybeboard holds cards under consideration. It is never counted by
+	// validation.
+	Maybeboard []DeckCard `json:"maybeboard,omitempty"`
+
+	// MTG Commander-specific. Commander is the single-commander form used by
+	// the web app; Commanders holds partner pairs.
+	Commander  *DeckCard  `json:"commander,omitempty"`
+	Commanders []DeckCard `json:"commanders,omitempty"`
+	Companion  *DeckCard  `json:"companion,omitempty"`
+
+	// MTG Oathbreaker-specific.
+	Oathbreaker    *DeckCard `json:"oathbreaker,omitempty"`
+	SignatureSpell *DeckCard `json:"signatureSpell,omitempty"`
+
+	// Riftbound-specific. Battlefield is the legacy single-battlefield
+	// field; new decks use Battlefields.
+	Legend       *DeckCard  `json:"legend,omitempty"`
+	Battlefield  *DeckCard  `json:"battlefield,omitempty"`
+	Battlefields []DeckCard `json:"battlefields,omitempty"`
+	Runes        []DeckCard `json:"runeDeck,omitempty"`
 }
 
+// maxWarnings is the number of warnings a deck may have before it is
+// rejected. A negative value means unlimited.
+var maxWarnings = -1
+
+// allowedGames restricts validation to the listed games. When empty, any
+// game is accepted and games the validator has no rules for get a warning.
+var allowedGames []string
+
+// knownGames are the games validateDeck has rules for.
+var knownGames = map[string]bool{"mtg": true, "riftbound": true}
+
 func main() {
+	rulesPath := flag.String("rules", "", "path to a JSON rules file layered over the built-in defaults")
+	cardsPaths := flag.String("cards", "", "comma-separated paths to JSON card database files")
+	flag.IntVar(&maxWarnings, "max-warnings", -1, "reject decks with more than this many warnings (-1 for unlimited)")
+	flag.BoolVar(&strictTags, "strict-tags", false, "reject decks with tags outside the configured allowedTags")
+	flag.BoolVar(&enforceAuthor, "enforce-author", false, "reject decks whose author doesn't match the authenticated Gitea user")
+	flag.StringVar(&adminToken, "admin-token", "", "bearer token for the /admin endpoints (disabled when empty)")
+	recentSize := flag.Int("recent-validations", 100, "number of recent validations kept for /admin/recent-validations")
+	flag.BoolVar(&requireIDs, "require-ids", false, "reject cards without an ID in constructed formats")
+	cacheSize := flag.Int("cache-size", 1000, "number of validation results to cache (0 to disable)")
+	games := flag.String("allowed-games", "", "comma-separated games to accept; decks for other games are rejected (empty to accept any game)")
+	cacheTTL := flag.Duration("cache-ttl", 10*time.Minute, "how long a cached validation result stays fresh (0 for no expiry)")
+	imageDir := flag.String("image-cache-dir", filepath.Join(os.TempDir(), "gitea-deck-plugin-images"), "directory card images are cached in")
+	imageTTL := flag.Duration("image-cache-ttl", 24*time.Hour, "how long a cached card image stays fresh (0 for no expiry)")
+	imageConcurrency := flag.Int("image-fetch-concurrency", 4, "maximum concurrent card image fetches")
+	imageInterval := flag.Duration("image-fetch-interval", 100*time.Millisecond, "minimum time between card image fetches")
+	providerConcurrency := flag.Int("provider-concurrency", 8, "maximum concurrent calls to external providers across all endpoints")
+	providerWait := flag.Duration("provider-wait", 5*time.Second, "how long a call waits for a free external provider slot before failing with 503")
+	flag.Int64Var(&maxBodyBytes, "max-body-bytes", 10<<20, "reject request bodies larger than this many bytes with 413")
+	flag.IntVar(&maxCardEntries, "max-card-entries", 5000, "reject requests declaring more than this many card entries with 413 (0 for unlimited)")
+	staticDir := flag.String("static-dir", "", "serve the viewer's static assets from this directory instead of the embedded copy")
+	registerFeatureFlags()
+	flag.Parse()
+
+	if err := loadStaticFiles(*staticDir); err != nil {
+		log.Fatalf("loading static assets: %v", err)
+	}
+	if maxCardEntries < 0 || maxBodyBytes <= 0 {
+		log.Fatal("-max-card-entries must not be negative and -max-body-bytes must be positive")
+	}
+	if *recentSize < 0 {
+		log.Fatal("-recent-validations must not be negative")
+	}
+	recentValidations = newValidationLog(*recentSize)
+	if *cacheSize < 0 || *cacheTTL < 0 {
+		log.Fatal("-cache-size and -cache-ttl must not be negative")
+	}
+	resultCache = newValidationCache(*cacheSize, *cacheTTL)
+	for _, game := range strings.Split(*games, ",") {
+		if game = strings.ToLower(strings.TrimSpace(game)); game != "" {
+			allowedGames = append(allowedGames, game)
+		}
+	}
+	if *imageConcurrency <= 0 || *imageTTL < 0 || *imageInterval < 0 {
+		log.Fatal("-image-fetch-concurrency must be positive and -image-cache-ttl and -image-fetch-interval must not be negative")
+	}
+	if *providerConcurrency <= 0 || *providerWait < 0 {
+		log.Fatal("-provider-concurrency must be positive and -provider-wait must not be negative")
+	}
+	providerLimit = newProviderLimiter(*providerConcurrency, *providerWait)
+	go batchJobs.purgeEvery(time.Minute)
+	imageProxy = newCardImageProxy(*imageDir, *imageTTL, *imageConcurrency, *imageInterval)
+
+	if *rulesPath != "" {
+		loaded, err := loadRules(*rulesPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		rules = loaded
+	}
+	rulesVersion = computeRulesVersion(rules)
+	if *cardsPaths != "" {
+		db, err := loadCardDB(strings.Split(*cardsPaths, ","))
+		if err != nil {
+			log.Fatal(err)
+		}
+		cardDB = db
+	}
+
 	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
+	r.Use(envelope)
+	r.Use(limitBody)
+	r.NotFound(notFoundHandler)
+	r.MethodNotAllowed(methodNotAllowedHandler(r))
 
-	// API endpoints
+	// API endpoints. Optional groups are only registered when enabled, so
+	// disabled endpoints fall through to the 404 handler.
 	r.Route("/api/deck", func(r chi.Router) {
-		r.Get("/parse", parseDeckHandler)
-		r.Get("/validate", validateDeckHandler)
+		// Validate Batch counts card entries itself as it decodes each deck,
+		// so it can stream without buffering the body.
+		r.Post("/validate-batch", validateBatchHandler)
+		r.Group(func(r chi.Router) {
+			r.Use(limitCardEntries)
+			r.Get("/parse", parseDeckHandler)
+			r.Post("/parse-markdown", parseMarkdownHandler)
+			r.Get("/canonicalize", canonicalizeHandler)
+			r.Post("/canonicalize", canonicalizeHandler)
+			r.Get("/validate", validateDeckHandler)
+			r.Post("/validate", validateDeckHandler)
+			r.Post("/validate-batch-job", createBatchJobHandler)
+			r.Get("/validate-batch-stream", validateBatchStreamHandler)
+			r.Get("/validate-structure", validateStructureHandler)
+			r.Post("/validate-structure", validateStructureHandler)
+			r.Post("/best-format", bestFormatHandler)
+			r.Get("/explain", explainCardHandler)
+			r.Post("/explain", explainCardHandler)
+			r.Get("/format-rules", formatRulesHandler)
+			r.Post("/apply-edit", applyEditHandler)
+			r.Post("/preview-edit", previewEditHandler)
+			r.Post("/infer-types", inferTypesHandler)
+			r.Get("/schema", deckSchemaHandler)
+			if featureEnabled("cube") {
+				r.Post("/validate-cube", validateCubeHandler)
+			}
+			if featureEnabled("split") {
+				r.Post("/split", splitDeckHandler)
+			}
+			if featureEnabled("fixes") {
+				r.Post("/suggest-fix", suggestFixHandler)
+				r.Get("/to-legal", toLegalHandler)
+				r.Post("/to-legal", toLegalHandler)
+			}
+			if featureEnabled("export") {
+				r.Get("/export", exportDeckHandler)
+				r.Post("/export-batch", exportBatchHandler)
+			}
+			if featureEnabled("import") {
+				r.Get("/import", importDeckHandler)
+				r.Post("/import", importDeckHandler)
+			}
+			if featureEnabled("lint") {
+				r.Get("/lint", lintDeckHandler)
+				r.Post("/lint", lintDeckHandler)
+				r.Get("/completeness", completenessHandler)
+				r.Post("/completeness", completenessHandler)
+			}
+			if featureEnabled("stats") {
+				r.Get("/stats", deckStatsHandler)
+				r.Post("/stats", deckStatsHandler)
+				r.Get("/count-histogram", countHistogramHandler)
+				r.Post("/count-histogram", countHistogramHandler)
+				r.Get("/power", deckPowerHandler)
+				r.Post("/power", deckPowerHandler)
+				r.Get("/detect-bracket", detectBracketHandler)
+				r.Post("/detect-bracket", detectBracketHandler)
+				r.Get("/land-probability", landProbabilityHandler)
+				r.Post("/simulate", simulateHandler)
+				r.Post("/combo-probability", comboProbabilityHandler)
+				r.Get("/tokens-needed", tokensNeededHandler)
+				r.Post("/tokens-needed", tokensNeededHandler)
+			}
+			if featureEnabled("share") {
+				r.Get("/qr", deckQRHandler)
+				r.Get("/encode", encodeDeckHandler)
+				r.Post("/encode", encodeDeckHandler)
+				r.Get("/decode", decodeDeckHandler)
+			}
+			if featureEnabled("colors") {
+				r.Get("/colors", deckColorsHandler)
+				r.Post("/colors", deckColorsHandler)
+				r.Get("/by-color", deckByColorHandler)
+				r.Post("/by-color", deckByColorHandler)
+			}
+		})
 	})
+	if adminToken != "" {
+		r.With(requireAdminToken).Get("/admin/recent-validations", recentValidationsHandler)
+		r.With(requireAdminToken).Get("/admin/cache-stats", cacheStatsHandler)
+		r.With(requireAdminToken).Get("/admin/stats", validationStatsHandler)
+	}
+	if featureEnabled("import") {
+		r.Post("/api/collection/import", importCollectionHandler)
+	}
+	if featureEnabled("cards") {
+		r.Get("/api/cards/search", searchCardsHandler)
+		r.Get("/api/cards/image", cardImageHandler)
+		r.With(limitCardEntries).Post("/api/cards/index", cardIndexHandler)
+	}
 
 	// Serve static files for the viewer
-	r.Get("/viewer/*", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, "./static/viewer.html")
-	})
+	if featureEnabled("viewer") {
+		r.Get("/viewer/*", viewerHandler)
+		r.Handle("/static/*", staticHandler())
+	}
 
 	port := ":8080"
 	log.Printf("Gitea Deck Plugin starting on %s", port)
 	log.Fatal(http.ListenAndServe(port, r))
 }
 
+// readDeck decodes the deck for endpoints that accept either a content query
+// parameter or, for POST requests, a JSON request body.
+func readDeck(r *http.Request) (*Deck, error) {
+	var deck Deck
+	if content := r.URL.Query().Get("content"); content != "" {
+		if err := json.Unmarshal([]byte(content), &deck); err != nil {
+			return nil, fmt.Errorf("invalid deck JSON: %v", err)
+		}
+		return &deck, nil
+	}
+	if r.Method != http.MethodPost {
+		return nil, errors.New("content parameter required")
+	}
+	if err := json.NewDecoder(r.Body).Decode(&deck); err != nil {
+		return nil, fmt.Errorf("invalid deck JSON: %v", err)
+	}
+	return &deck, nil
+}
+
 func parseDeckHandler(w http.ResponseWriter, r *http.Request) {
 	content := r.URL.Query().Get("content")
 	if content == "" {
@@ -69,39 +318,222 @@ func parseDeckHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Query().Get("compact") == "true" {
+		json.NewEncoder(w).Encode(compactDeck(&deck))
+		return
+	}
 	json.NewEncoder(w).Encode(deck)
 }
 
+// playgroupBanHeader names one card on the request's playgroup banlist. It
+// may be repeated.
+const playgroupBanHeader = "X-Deck-Playgroup-Ban"
+
+// validateRequest is the POST body of Validate Deck: the deck, plus an
+// optional playgroup banlist.
+type validateRequest struct {
+	Deck
+	PlaygroupBans []string `json:"playgroupBans,omitempty"`
+}
+
 func validateDeckHandler(w http.ResponseWriter, r *http.Request) {
-	content := r.URL.Query().Get("content")
-	if content == "" {
+	var req validateRequest
+	if content := r.URL.Query().Get("content"); content != "" {
+		if err := json.Unmarshal([]byte(content), &req.Deck); err != nil {
+			http.Error(w, fmt.Sprintf("invalid deck JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+	} else if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid deck JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+	} else {
 		http.Error(w, "content parameter required", http.StatusBadRequest)
 		return
 	}
+	deck := req.Deck
 
-	var deck Deck
-	if err := json.Unmarshal([]byte(content), &deck); err != nil {
-		http.Error(w, fmt.Sprintf("invalid deck JSON: %v", err), http.StatusBadRequest)
+	mergedSideboard := false
+	if sb := r.URL.Query().Get("sideboard"); sb != "" {
+		var sideboard []DeckCard
+		if err := json.Unmarshal([]byte(sb), &sideboard); err != nil {
+			http.Error(w, fmt.Sprintf("invalid sideboard JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+		mergedSideboard = len(deck.Sideboard) > 0
+		deck.Sideboard = append(deck.Sideboard, sideboard...)
+		if err := checkCardEntries(deckEntries(&deck)); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+	}
+
+	groupBy := r.URL.Query().Get("group-by")
+	if groupBy != "" && groupBy != "card" {
+		http.Error(w, "group-by must be card", http.StatusBadRequest)
+		return
+	}
+	maxErrors, err := limitParam(r, "max-errors")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	maxWarningsShown, err := limitParam(r, "max-warnings")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	validation := validateDeck(&deck)
+	opts := validateOptionsFromRequest(r)
+	opts.PlaygroupBans = append(opts.PlaygroupBans, req.PlaygroupBans...)
+	if v := r.URL.Query().Get("as-of"); v != "" {
+		asOf, err := time.Parse(rulesChangeDate, v)
+		if err != nil {
+			http.Error(w, "as-of must be a YYYY-MM-DD date", http.StatusBadRequest)
+			return
+		}
+		opts.AsOf = asOf
+	}
+	key := cacheKey(&deck, opts)
+	validation, cached := resultCache.get(key)
+	if !cached {
+		validation = validateDeck(&deck, opts)
+		resultCache.put(key, validation)
+	}
+	if mergedSideboard {
+		validation.addWarning(CodeSideboardMerged, "")
+		if opts.WarningsAsErrors {
+			validation.escalateWarnings()
+		}
+	}
+	record := validationRecord{
+		Time:      time.Now().UTC(),
+		Game:      deck.Game,
+		Format:    deck.Format,
+		Valid:     validation.Valid,
+		Errors:    len(validation.Errors),
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+	recentValidations.add(record)
+	validationCounts.add(record)
 
+	localizeResult(w, r, &validation)
+	validation.Errors = truncateMessages(validation.Errors, maxErrors)
+	validation.Warnings = truncateMessages(validation.Warnings, maxWarningsShown)
+	if groupBy == "card" {
+		validation.ByCard = groupIssuesByCard(validation.Issues)
+	}
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(formatText(validation, &deck)))
+		return
+	}
+	if !validation.Valid && wantsProblem(r) {
+		w.Header().Set("Content-Type", problemJSON)
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(toProblemDetails(validation))
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(validation)
 }
 
+// limitParam reads an optional positive integer query parameter, returning
+// -1 when it is absent.
+func limitParam(r *http.Request, name string) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return -1, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("%s must be a positive integer", name)
+	}
+	return n, nil
+}
+
+// truncateMessages keeps the first max messages and replaces the rest with a
+// single "...and N more" line. A negative max keeps every message.
+func truncateMessages(messages []string, max int) []string {
+	if max < 0 || len(messages) <= max {
+		return messages
+	}
+	return append(messages[:max:max], fmt.Sprintf("...and %d more", len(messages)-max))
+}
+
 type ValidationResult struct {
 	Valid    bool     `json:"valid"`
 	Errors   []string `json:"errors"`
 	Warnings []string `json:"warnings"`
+	Issues   []Issue  `json:"issues"`
+
+	// ByCard regroups Issues by card when Validate Deck is asked to with
+	// group-by=card.
+	ByCard map[string][]Issue `json:"byCard,omitempty"`
+}
+
+// ValidateOptions adjusts how validateDeck runs for a single request.
+type ValidateOptions struct {
+	// SkipAdvisory suppresses heuristic warnings that don't affect legality.
+	SkipAdvisory bool
+	// AllowCustom suppresses unknown-card warnings for cards marked Custom.
+	AllowCustom bool
+	// User is the authenticated user the deck's author is checked against,
+	// or "" to skip the check.
+	User string
+	// WarningsAsErrors fails decks with any warning, for strict CI.
+	WarningsAsErrors bool
+	// AsOf is the date set legality and banned lists are evaluated at. The
+	// zero value means now.
+	AsOf time.Time
+	// HeaderOverrides holds the override headers sent with the request,
+	// keyed by header name. See overrideHeaders.
+	HeaderOverrides map[string]string
+	// PlaygroupBans is a casual banlist layered over the format's: cards on
+	// it are warnings rather than errors.
+	PlaygroupBans []string
 }
 
-func validateDeck(deck *Deck) ValidationResult {
-	result := ValidationResult{
+func validateOptionsFromRequest(r *http.Request) ValidateOptions {
+	q := r.URL.Query()
+	opts := ValidateOptions{
+		SkipAdvisory:     q.Get("skip-advisory") == "true",
+		AllowCustom:      q.Get("allow-custom") == "true",
+		User:             r.Header.Get(userHeader),
+		WarningsAsErrors: q.Get("warnings-as-errors") == "true",
+		PlaygroupBans:    r.Header.Values(playgroupBanHeader),
+	}
+	for header := range overrideHeaders {
+		if v := r.Header.Get(header); v != "" {
+			if opts.HeaderOverrides == nil {
+				opts.HeaderOverrides = map[string]string{}
+			}
+			opts.HeaderOverrides[header] = v
+		}
+	}
+	return opts
+}
+
+func newValidationResult() ValidationResult {
+	return ValidationResult{
 		Valid:    true,
 		Errors:   []string{},
 		Warnings: []string{},
+		Issues:   []Issue{},
+	}
+}
+
+func validateDeck(deck *Deck, opts ValidateOptions) ValidationResult {
+	result := newValidationResult()
+
+	if canonical := canonicalFormat(deck.Format); canonical != deck.Format {
+		if canonical != strings.ToLower(deck.Format) {
+			result.addInfo(CodeFormatAliased, "", deck.Format, canonical)
+		}
+		normalized := *deck
+		normalized.Format = canonical
+		deck = &normalized
 	}
 
 	totalCards := 0
@@ -109,31 +541,398 @@ func validateDeck(deck *Deck) ValidationResult {
 		totalCards += card.Count
 	}
 
+	// A deck with no main deck cards is usually a file that was just
+	// created, even if a commander or entries with a count of 0 are
+	// filled in; one error says more than every format's size messages.
+	if totalCards == 0 {
+		result.addError(CodeEmptyDeck, "")
+		return result
+	}
+
+	checkGame(deck.Game, &result)
+	checkFieldConsistency(deck, &result)
+	overrides := readOverrides(deck, opts, &result)
+	checkKnownCards(deck, opts, &result)
+	checkCardIDs(deck, opts, &result)
+	checkDeckName(deck.Game, deck.Name, &result)
+	checkAuthor(deck, opts, &result)
+	checkTags(deck, &result)
+
 	// MTG validation
 	if deck.Game == "mtg" {
-		if deck.Format == "commander" && totalCards != 100 {
-			result.Valid = false
-			result.Errors = append(result.Errors, fmt.Sprintf("Commander decks must have exactly 100 cards. Current: %d", totalCards))
-		} else if (deck.Format == "standard" || deck.Format == "modern") && totalCards < 60 {
-			result.Valid = false
-			result.Errors = append(result.Errors, fmt.Sprintf("%s decks must have at least 60 cards. Current: %d", strings.Title(deck.Format), totalCards))
+		// size is the format's deck size, used for the land-count advisory
+		// and companion deck size conditions.
+		size := 0
+		asOf := opts.AsOf
+		if asOf.IsZero() {
+			asOf = time.Now()
+		}
+		active := rulesAsOf(asOf)
+		checkBannedCards(deck, active, &result)
+		switch deck.Format {
+		case "commander":
+			size = overrides.deckSize(100)
+			checkExactSize("Commander", size, totalCards, &result)
+			checkCopyLimit(deck, overrides.maxCopies(1), &result)
+			if overrides.maxCopies(1) == 1 {
+				checkSingletonUnique(deck, &result)
+			}
+			checkCommanderPair(deck, &result)
+			checkColorIdentity(deck, &result)
+			checkBracket(deck, &result)
+		case "standard":
+			size = overrides.deckSize(60)
+			checkMinSize("Standard", size, totalCards, &result)
+			checkCopyLimit(deck, overrides.maxCopies(4), &result)
+			checkSetLegality(deck, active, &result)
+		case "modern":
+			size = overrides.deckSize(60)
+			checkMinSize("Modern", size, totalCards, &result)
+			checkCopyLimit(deck, overrides.maxCopies(4), &result)
+		case "legacy":
+			size = overrides.deckSize(60)
+			checkMinSize("Legacy", size, totalCards, &result)
+			checkCopyLimit(deck, overrides.maxCopies(4), &result)
+			checkSideboardSize("Legacy", 15, deck, &result)
+		case "canlander":
+			// Canadian Highlander: 100-card singleton with a points list
+			size = overrides.deckSize(100)
+			checkMinSize("Canadian Highlander", size, totalCards, &result)
+			checkCopyLimit(deck, overrides.maxCopies(1), &result)
+			if overrides.maxCopies(1) == 1 {
+				checkSingletonUnique(deck, &result)
+			}
+			checkCanlanderPoints(deck, &result)
+		case "oathbreaker":
+			// Oathbreaker: 58 singleton cards plus the oathbreaker and
+			// signature spell
+			size = overrides.deckSize(58)
+			checkExactSize("Oathbreaker", size, totalCards, &result)
+			checkCopyLimit(deck, overrides.maxCopies(1), &result)
+			if overrides.maxCopies(1) == 1 {
+				checkSingletonUnique(deck, &result)
+			}
+			checkOathbreaker(deck, &result)
+		case "pool":
+			result.merge(validatePool(deck))
+		case "":
+		default:
+			format, ok := rules.Formats[deck.Format]
+			if !ok {
+				result.addWarning(CodeUnknownFormat, "", deck.Format)
+				break
+			}
+			size = overrides.deckSize(format.MinSize)
+			checkConfiguredFormat(deck, format, overrides, totalCards, active, &result)
+		}
+
+		if deck.Companion != nil {
+			checkCompanion(deck, size, &result)
+		}
+
+		// Every MTG deck needs a way to win, not just Commander decks; pools
+		// are card lists that haven't been built into a deck yet.
+		if !opts.SkipAdvisory && deck.Format != "pool" && !hasWinCondition(deck) {
+			result.addWarning(CodeNoWinCondition, "")
+		}
+		if !opts.SkipAdvisory && size > 0 {
+			checkLandRatio(deck, size, &result)
+		}
+		if !opts.SkipAdvisory {
+			checkThemes(deck, &result)
 		}
 	}
 
 	// Riftbound validation
 	// Riftbound decks are exactly 40 cards (not including legend, 12 rune cards, and 3 battlefields)
 	if deck.Game == "riftbound" {
-		if totalCards != 40 {
-			result.Valid = false
-			result.Errors = append(result.Errors, fmt.Sprintf("Riftbound decks must have exactly 40 cards. Current: %d", totalCards))
-		}
+		checkExactSize("Riftbound", overrides.deckSize(40), totalCards, &result)
 		if deck.Legend == nil {
-			result.Warnings = append(result.Warnings, "No Legend selected")
+			result.addWarning(CodeMissingLegend, "")
 		}
-		if deck.Battlefield == nil {
-			result.Warnings = append(result.Warnings, "No Battlefield selected")
+		if len(deck.Runes) > 0 {
+			runes := 0
+			for _, card := range deck.Runes {
+				runes += card.Count
+			}
+			if runes != 12 {
+				result.addError(CodeRuneCount, "", 12, runes)
+			}
 		}
+		checkBattlefields(deck, &result)
+	}
+
+	if !opts.SkipAdvisory {
+		checkResourceFloor(deck, &result)
+	}
+	checkPlaygroupBans(deck, opts.PlaygroupBans, &result)
+	checkBannedCombos(deck, rules.BannedCombos[deck.Format], &result)
+
+	if maxWarnings >= 0 && len(result.Warnings) > maxWarnings {
+		result.addError(CodeTooManyWarnings, "", len(result.Warnings), maxWarnings)
+	}
+	if opts.WarningsAsErrors {
+		result.escalateWarnings()
 	}
 
 	return result
 }
+
+func checkExactSize(format string, size, total int, result *ValidationResult) {
+	if total != size {
+		result.addError(CodeDeckSizeMismatch, "", format, size, total)
+	}
+}
+
+func checkSideboardSize(format string, max int, deck *Deck, result *ValidationResult) {
+	total := 0
+	for _, card := range deck.Sideboard {
+		total += card.Count
+	}
+	if total > max {
+		result.addError(CodeSideboardTooLarge, "", format, max, total)
+	}
+}
+
+func checkMinSize(format string, size, total int, result *ValidationResult) {
+	if total < size {
+		result.addError(CodeDeckSizeTooSmall, "", format, size, total)
+	}
+}
+
+// commandersOf returns every commander declared on the deck, whichever
+// field it was declared in.
+func commandersOf(deck *Deck) []DeckCard {
+	var cmdrs []DeckCard
+	if deck.Commander != nil {
+		cmdrs = append(cmdrs, *deck.Commander)
+	}
+	return append(cmdrs, deck.Commanders...)
+}
+
+// playedCards returns the cards in every zone that is part of the deck:
+// commanders, companion, oathbreaker and signature spell, main deck,
+// sideboard and the Riftbound zones. The
+// maybeboard is not included.
+func playedCards(deck *Deck) []DeckCard {
+	cards := commandersOf(deck)
+	if deck.Companion != nil {
+		cards = append(cards, *deck.Companion)
+	}
+	if deck.Oathbreaker != nil {
+		cards = append(cards, *deck.Oathbreaker)
+	}
+	if deck.SignatureSpell != nil {
+		cards = append(cards, *deck.SignatureSpell)
+	}
+	cards = append(cards, deck.Cards...)
+	cards = append(cards, deck.Sideboard...)
+	if deck.Legend != nil {
+		cards = append(cards, *deck.Legend)
+	}
+	cards = append(cards, battlefieldsOf(deck)...)
+	return append(cards, deck.Runes...)
+}
+
+// battlefieldsOf returns every battlefield declared on the deck, whichever
+// field it was declared in.
+func battlefieldsOf(deck *Deck) []DeckCard {
+	var bfs []DeckCard
+	if deck.Battlefield != nil {
+		bfs = append(bfs, *deck.Battlefield)
+	}
+	return append(bfs, deck.Battlefields...)
+}
+
+// checkBattlefields errors unless a Riftbound deck has exactly 3
+// battlefields, and warns when the same battlefield is chosen twice.
+func checkBattlefields(deck *Deck, result *ValidationResult) {
+	bfs := battlefieldsOf(deck)
+	if len(bfs) == 0 {
+		result.addWarning(CodeMissingBattlefield, "")
+		return
+	}
+
+	total := 0
+	seen := map[string]int{}
+	for _, bf := range bfs {
+		total += bf.Count
+		key := cardKey(deck.Game, bf)
+		seen[key] += bf.Count
+		if seen[key] > 1 && seen[key]-bf.Count <= 1 {
+			result.addWarning(CodeDuplicateBattlefield, displayName(bf), displayName(bf))
+		}
+	}
+	if total != 3 {
+		result.addError(CodeBattlefieldCount, "", 3, total)
+	}
+}
+
+// checkGame errors for games outside -allowed-games when it is set, and
+// otherwise warns about games the validator has no rules for.
+func checkGame(game string, result *ValidationResult) {
+	if len(allowedGames) == 0 {
+		if !knownGames[game] {
+			result.addWarning(CodeUnknownGame, "", game)
+		}
+		return
+	}
+	for _, allowed := range allowedGames {
+		if strings.ToLower(game) == allowed {
+			return
+		}
+	}
+	result.addError(CodeGameNotAllowed, "", game, strings.Join(allowedGames, ", "))
+}
+
+// checkFieldConsistency flags zones that don't belong to the deck's game or
+// format, which usually means the deck was converted between games
+// incorrectly.
+func checkFieldConsistency(deck *Deck, result *ValidationResult) {
+	hasCommanders := len(commandersOf(deck)) > 0
+	hasRiftboundZones := deck.Legend != nil || len(battlefieldsOf(deck)) > 0 || len(deck.Runes) > 0
+
+	if deck.Legend != nil && hasCommanders {
+		result.addError(CodeConflictingGameFields, "")
+	}
+
+	switch deck.Game {
+	case "mtg":
+		if hasRiftboundZones {
+			result.addWarning(CodeForeignZoneIgnored, "")
+		}
+		if deck.Format == "commander" && len(deck.Sideboard) > 0 {
+			result.addError(CodeSideboardNotAllowed, "", "Commander")
+		}
+		if deck.Format != "commander" && hasCommanders {
+			result.addWarning(CodeCommandersIgnored, "", formatDisplayName(deck.Format))
+		}
+	case "riftbound":
+		if len(deck.Sideboard) > 0 {
+			result.addError(CodeSideboardNotAllowed, "", "Riftbound")
+		}
+		if hasCommanders {
+			result.addError(CodeCommandersNotAllowed, "", "Riftbound")
+		}
+		if deck.Companion != nil {
+			result.addError(CodeCompanionNotAllowed, "", "Riftbound")
+		}
+	}
+}
+
+var basicLandTypes = map[string]bool{
+	"plains":   true,
+	"island":   true,
+	"swamp":    true,
+	"mountain": true,
+	"forest":   true,
+	"wastes":   true,
+}
+
+// isBasicLand reports whether name is a basic land, including the
+// snow-covered basics and Wastes. Matching ignores case and extra spacing.
+func isBasicLand(name string) bool {
+	n := strings.ToLower(strings.Join(strings.Fields(name), " "))
+	n = strings.TrimPrefix(n, "snow-covered ")
+	return basicLandTypes[n]
+}
+
+// checkCopyLimit errors for every card with more than limit copies in the
+// main deck, and separately for cards whose copies across the main deck,
+// sideboard and companion slot together exceed it. Entries are grouped by
+// normalized front face name, so variant spellings of the same card and its
+// "Fire // Ice" full name are counted together. Basic lands are exempt, and
+// cards in the game's copy-limit exceptions use their own limit instead.
+func checkCopyLimit(deck *Deck, limit int, result *ValidationResult) {
+	checkCopyLimits(deck, func(DeckCard) (int, string) { return limit, "" }, result)
+}
+
+// checkCopyLimits is checkCopyLimit with a limit per card. limitFor gets
+// the card's first entry that has a rarity, or its first entry, and
+// returns the limit along with the rarity it comes from, or "" for the
+// format's default limit; 0 means unlimited. Cards over a rarity limit get
+// RARITY_LIMIT_EXCEEDED instead of COPY_LIMIT_EXCEEDED.
+func checkCopyLimits(deck *Deck, limitFor func(DeckCard) (int, string), result *ValidationResult) {
+	// counts are main deck copies and totals copies across the main deck,
+	// sideboard and companion slot, the zones a copy limit covers.
+	counts := map[string]int{}
+	totals := map[string]int{}
+	firsts := map[string]DeckCard{}
+	var order []string
+	for _, card := range deck.Cards {
+		key := faceKey(deck.Game, card)
+		if _, seen := counts[key]; !seen {
+			order = append(order, key)
+		}
+		if first, seen := firsts[key]; !seen || first.Rarity == "" && card.Rarity != "" {
+			firsts[key] = card
+		}
+		counts[key] += card.Count
+		totals[key] += card.Count
+	}
+
+	others := append([]DeckCard{}, deck.Sideboard...)
+	if deck.Companion != nil {
+		others = append(others, *deck.Companion)
+	}
+	for _, card := range others {
+		key := faceKey(deck.Game, card)
+		if _, seen := firsts[key]; !seen {
+			order = append(order, key)
+			firsts[key] = card
+		}
+		totals[key] += card.Count
+	}
+
+	for _, key := range order {
+		name := displayName(firsts[key])
+		if isBasicLand(name) {
+			continue
+		}
+		max, rarity := 0, ""
+		if exception, ok := copyLimitException(deck.Game, name); ok {
+			max = exception
+		} else {
+			max, rarity = limitFor(firsts[key])
+		}
+		if max == 0 {
+			continue
+		}
+		switch {
+		case counts[key] > max && rarity != "":
+			result.addError(CodeRarityLimitExceeded, name, name, max, rarity, counts[key])
+		case counts[key] > max:
+			result.addError(CodeCopyLimitExceeded, name, name, max, counts[key])
+		}
+		if total := totals[key]; total > counts[key] && total > max {
+			result.addError(CodeSideboardCopyLimit, name, name, max, total)
+		}
+	}
+}
+
+// checkSingletonUnique cross-checks a singleton deck's unique card count
+// against its size. Entries are keyed by ID where they have one, so copies
+// of one card entered under different names, which the name-based copy
+// limit check can't see, still show up as duplicates. Basic lands and copy
+// limit exceptions are left out of both counts.
+func checkSingletonUnique(deck *Deck, result *ValidationResult) {
+	total := 0
+	unique := map[string]bool{}
+	for _, card := range deck.Cards {
+		if isBasicLand(card.Name) {
+			continue
+		}
+		if _, ok := copyLimitException(deck.Game, card.Name); ok {
+			continue
+		}
+		total += card.Count
+		if card.ID != "" {
+			unique["id:"+card.ID] = true
+		} else {
+			unique["name:"+cardKey(deck.Game, card)] = true
+		}
+	}
+	if len(unique) < total {
+		result.addWarning(CodeSingletonDuplicates, "", len(unique), total)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// issueCodes lists the codes of result's issues for card.
+func issueCodes(result ValidationResult, card string) []string {
+	var codes []string
+	for _, issue := range result.Issues {
+		if issue.Card == card {
+			codes = append(codes, issue.Code)
+		}
+	}
+	return codes
+}
+
+func hasIssue(result ValidationResult, code, card string) bool {
+	for _, c := range issueCodes(result, card) {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func TestIsBasicLand(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"Plains", true},
+		{"Forest", true},
+		{"Wastes", true},
+		{"wastes", true},
+		{"Snow-Covered Plains", true},
+		{"Snow-Covered Island", true},
+		{"Snow-Covered Swamp", true},
+		{"Snow-Covered Mountain", true},
+		{"snow-covered  forest", true},
+		{"Snow-Covered Wastes", true},
+		{"Snow-Covered Elks", false},
+		{"Plateau", false},
+		{"Wasteland", false},
+		{"Relentless Rats", false},
+	}
+	for _, tt := range tests {
+		if got := isBasicLand(tt.name); got != tt.want {
+			t.Errorf("isBasicLand(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCopyLimitExemptsBasics(t *testing.T) {
+	tests := []struct {
+		format string
+		card   string
+		count  int
+		limit  int
+		want   bool // whether the copy limit is exceeded
+	}{
+		{"commander", "Snow-Covered Island", 30, 1, false},
+		{"commander", "Wastes", 10, 1, false},
+		{"commander", "Sol Ring", 2, 1, true},
+		{"modern", "Snow-Covered Forest", 20, 4, false},
+		{"modern", "Wastes", 12, 4, false},
+		{"modern", "Snow-Covered Elks", 5, 4, true},
+	}
+	for _, tt := range tests {
+		deck := &Deck{Game: "mtg", Format: tt.format, Cards: []DeckCard{{Name: tt.card, Count: tt.count}}}
+		var result ValidationResult
+		checkCopyLimit(deck, tt.limit, &result)
+		if got := hasIssue(result, CodeCopyLimitExceeded, tt.card); got != tt.want {
+			t.Errorf("%s with %d %s: exceeded = %v, want %v (errors %v)", tt.format, tt.count, tt.card, got, tt.want, result.Errors)
+		}
+		if tt.limit == 1 {
+			result = ValidationResult{}
+			checkSingletonUnique(deck, &result)
+			if got := len(result.Warnings) > 0; got != tt.want {
+				t.Errorf("%s with %d %s: singleton duplicates = %v, want %v", tt.format, tt.count, tt.card, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestCopyLimitAcrossZones(t *testing.T) {
+	tests := []struct {
+		name      string
+		main      int
+		side      int
+		companion bool
+		want      bool // whether SIDEBOARD_COPY_LIMIT is reported
+	}{
+		{"3 main and 2 sideboard", 3, 2, false, true},
+		{"2 main and 2 sideboard", 2, 2, false, false},
+		{"4 main only", 4, 0, false, false},
+		{"3 main, 0 sideboard and the companion", 3, 0, true, false},
+		{"3 main, 1 sideboard and the companion", 3, 1, true, true},
+	}
+	for _, tt := range tests {
+		deck := &Deck{Game: "mtg", Format: "modern", Cards: []DeckCard{{Name: "Lurrus of the Dream-Den", Count: tt.main}}}
+		if tt.side > 0 {
+			deck.Sideboard = []DeckCard{{Name: "lurrus of the dream-den", Count: tt.side}}
+		}
+		if tt.companion {
+			deck.Companion = &DeckCard{Name: "Lurrus of the Dream-Den", Count: 1}
+		}
+		var result ValidationResult
+		checkCopyLimit(deck, 4, &result)
+		if got := hasIssue(result, CodeSideboardCopyLimit, "Lurrus of the Dream-Den"); got != tt.want {
+			t.Errorf("%s: cross-zone limit exceeded = %v, want %v (errors %v)", tt.name, got, tt.want, result.Errors)
+		}
+		if hasIssue(result, CodeCopyLimitExceeded, "Lurrus of the Dream-Den") {
+			t.Errorf("%s: main deck alone reported over the limit", tt.name)
+		}
+	}
+}
+
+func TestEmptyDeck(t *testing.T) {
+	commander := &DeckCard{Name: "Atraxa, Praetors' Voice", Count: 1}
+	tests := []struct {
+		name string
+		deck *Deck
+		want bool // whether the deck is reported as just EMPTY_DECK
+	}{
+		{"no cards", &Deck{Game: "mtg", Format: "modern"}, true},
+		{"empty cards", &Deck{Game: "mtg", Format: "modern", Cards: []DeckCard{}}, true},
+		{"zero counts", &Deck{Game: "mtg", Format: "modern", Cards: []DeckCard{{Name: "Island", Count: 0}, {Name: "Opt", Count: 0}}}, true},
+		{"commander only", &Deck{Game: "mtg", Format: "commander", Commander: commander}, true},
+		{"sideboard only", &Deck{Game: "mtg", Format: "modern", Sideboard: []DeckCard{{Name: "Negate", Count: 2}}}, true},
+		{"one card", &Deck{Game: "mtg", Format: "modern", Cards: []DeckCard{{Name: "Island", Count: 1}}}, false},
+	}
+	for _, tt := range tests {
+		result := validateDeck(tt.deck, ValidateOptions{})
+		got := len(result.Issues) == 1 && result.Issues[0].Code == CodeEmptyDeck
+		if got != tt.want {
+			t.Errorf("%s: just EMPTY_DECK = %v, want %v (errors %v)", tt.name, got, tt.want, result.Errors)
+		}
+		if result.Valid {
+			t.Errorf("%s: deck is valid", tt.name)
+		}
+	}
+}
+
+func TestHasWinCondition(t *testing.T) {
+	tests := []struct {
+		name  string
+		cards []DeckCard
+		tags  []string
+		want  bool
+	}{
+		{"creature", []DeckCard{{Name: "Grizzly Bears", Type: "Creature — Bear"}}, nil, true},
+		{"planeswalker", []DeckCard{{Name: "Karn Liberated", Type: "Legendary Planeswalker — Karn"}}, nil, true},
+		{"known burn spell", []DeckCard{{Name: "Lightning Bolt", Type: "Instant"}}, nil, true},
+		{"alternate win condition", []DeckCard{{Name: "Approach of the Second Sun", Type: "Sorcery"}}, nil, true},
+		{"tagged burn", []DeckCard{{Name: "Lava Spike", Type: "Sorcery — Arcane"}}, []string{" Burn "}, true},
+		{"only spells", []DeckCard{{Name: "Opt", Type: "Instant"}, {Name: "Island", Type: "Basic Land — Island"}}, nil, false},
+		{"untyped deck", []DeckCard{{Name: "Opt"}}, nil, true},
+	}
+	for _, tt := range tests {
+		deck := &Deck{Game: "mtg", Format: "modern", Cards: tt.cards, Metadata: DeckMetadata{Tags: tt.tags}}
+		if got := hasWinCondition(deck); got != tt.want {
+			t.Errorf("%s: hasWinCondition = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPlaygroupBans(t *testing.T) {
+	saved := cardDB
+	defer func() { cardDB = saved }()
+	cardDB = testCardDB(t, `[{"id": "sol-1", "name": "Sol Ring", "game": "mtg"}]`)
+
+	tests := []struct {
+		name  string
+		cards []DeckCard
+		want  []string // cards with a PLAYGROUP_BANNED warning
+	}{
+		{"by name", []DeckCard{{Name: "sol ring", Count: 1}}, []string{"sol ring"}},
+		{"by ID", []DeckCard{{ID: "sol-1", Count: 1}}, []string{"Sol Ring"}},
+		{"by name and ID, once", []DeckCard{{ID: "sol-1", Count: 1}, {Name: "Sol Ring", Count: 1}}, []string{"Sol Ring"}},
+		{"unknown ID", []DeckCard{{ID: "nope", Count: 1}}, nil},
+		{"not on the list", []DeckCard{{Name: "Arcane Signet", Count: 1}}, nil},
+	}
+	for _, tt := range tests {
+		var result ValidationResult
+		checkPlaygroupBans(&Deck{Game: "mtg", Format: "commander", Cards: tt.cards}, []string{"Sol Ring"}, &result)
+		var got []string
+		for _, issue := range result.Issues {
+			if issue.Code == CodePlaygroupBanned {
+				got = append(got, issue.Card)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: warned about %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
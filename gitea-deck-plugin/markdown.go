@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// extractDeckFromMarkdown returns the contents of the first fenced code
+// block in md whose info string starts with "deck" or "json". Both backtick
+// and tilde fences are recognized; an unclosed block runs to the end of the
+// document, as in CommonMark.
+func extractDeckFromMarkdown(md string) (string, error) {
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		fence, info, ok := openingFence(lines[i])
+		if !ok {
+			continue
+		}
+		var body []string
+		for i++; i < len(lines); i++ {
+			line := strings.TrimSpace(lines[i])
+			if strings.HasPrefix(line, fence) && strings.Trim(line, fence[:1]) == "" {
+				break
+			}
+			body = append(body, lines[i])
+		}
+		lang := ""
+		if words := strings.Fields(info); len(words) > 0 {
+			lang = strings.ToLower(words[0])
+		}
+		if lang == "deck" || lang == "json" {
+			return strings.Join(body, "\n"), nil
+		}
+	}
+	return "", errors.New("no ```deck or ```json fenced code block found")
+}
+
+// openingFence reports whether line opens a fenced code block, returning the
+// fence and the info string after it.
+func openingFence(line string) (fence, info string, ok bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) < 3 {
+		return "", "", false
+	}
+	c := trimmed[0]
+	if c != '`' && c != '~' {
+		return "", "", false
+	}
+	n := len(trimmed) - len(strings.TrimLeft(trimmed, string(c)))
+	if n < 3 {
+		return "", "", false
+	}
+	info = strings.TrimSpace(trimmed[n:])
+	if c == '`' && strings.Contains(info, "`") {
+		return "", "", false
+	}
+	return trimmed[:n], info, true
+}
+
+type parseMarkdownResponse struct {
+	Deck       *Deck            `json:"deck"`
+	Validation ValidationResult `json:"validation"`
+}
+
+func parseMarkdownHandler(w http.ResponseWriter, r *http.Request) {
+	md, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("reading request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	content, err := extractDeckFromMarkdown(string(md))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// The body is Markdown, so limitCardEntries can't count the deck.
+	if _, err := countCardEntries(strings.NewReader(content)); err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	var deck Deck
+	if err := json.Unmarshal([]byte(content), &deck); err != nil {
+		http.Error(w, fmt.Sprintf("invalid deck JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	validation := validateDeck(&deck, validateOptionsFromRequest(r))
+	localizeResult(w, r, &validation)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(parseMarkdownResponse{Deck: &deck, Validation: validation})
+}
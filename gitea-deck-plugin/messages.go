@@ -0,0 +1,323 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultLocale is used when the client asks for no supported locale.
+const defaultLocale = "en"
+
+// messages holds the message template for each issue code, per locale.
+// Templates take the issue's args in order. A code missing from a locale
+// falls back to English.
+var messages = map[string]map[string]string{
+	"en": {
+		CodeDeckSizeMismatch:      "%s decks must have exactly %d cards. Current: %d",
+		CodeDeckSizeTooSmall:      "%s decks must have at least %d cards. Current: %d",
+		CodeCopyLimitExceeded:     "%s exceeds the copy limit of %d. Current: %d",
+		CodeSetNotLegal:           "%s is from set %s, which is not legal in %s",
+		CodeSetUnknown:            "Set legality could not be checked for cards without set data: %d",
+		CodeMissingLegend:         "No Legend selected",
+		CodeMissingBattlefield:    "No Battlefield selected",
+		CodeBattlefieldCount:      "Riftbound decks must have exactly %d battlefields. Current: %d",
+		CodeDuplicateBattlefield:  "Battlefield %s is included more than once",
+		CodeRuneCount:             "Riftbound rune decks must have exactly %d runes. Current: %d",
+		CodeConflictingGameFields: "Deck declares both a Riftbound Legend and MTG commanders",
+		CodeForeignZoneIgnored:    "MTG deck carries Riftbound Legend, Battlefield or Runes, which will be ignored",
+		CodeSideboardNotAllowed:   "%s decks cannot have a sideboard",
+		CodeSideboardMerged:       "Deck sideboard and sideboard parameter were both present and have been merged",
+		CodeCommandersIgnored:     "Commanders are ignored in %s decks",
+		CodeCommandersNotAllowed:  "%s decks cannot have commanders",
+		CodeCompanionNotAllowed:   "%s decks cannot have a companion",
+		CodeCompanionUncheckable:  "Companion restriction for %s can't be checked",
+		CodeCompanionViolation:    "%s violates %s's companion restriction: %s",
+		CodeCompanionUnchecked:    "Cards without type data could not be checked against %s's companion restriction: %d",
+		CodeNoWinCondition:        "Deck has no creatures, planeswalkers or known alternate win conditions",
+		CodeTooManyWarnings:       "Deck has %d warnings, more than the %d allowed",
+		CodeOverrideInEffect:      "Override %s=%d is in effect",
+		CodeOverrideUnknown:       "Unknown override %q ignored",
+		CodeOverrideInvalid:       "Override %s has invalid value %q and was ignored",
+		CodeUnknownCardID:         "Unknown card ID %s",
+		CodeUnknownCardName:       "Unknown card %s",
+		CodePointsCapExceeded:     "Deck has %d points, more than the %d allowed: %s",
+		CodePoolCardMissing:       "%s is not in the pool",
+		CodePoolCopiesExceeded:    "%s is used %d times but the pool only has %d",
+		CodeUnknownFormat:         "Unknown MTG format %q; only general checks were applied",
+		CodeFormatAliased:         "Format %q was treated as %q",
+		CodeAuthorMismatch:        "Deck author %q does not match the authenticated user %q",
+		CodeLandCount:             "Deck has %d lands; %d-%d are recommended",
+		CodeNotInCube:             "%s is not in the cube",
+		CodeBannedCard:            "%s is banned in %s",
+		CodeSideboardTooLarge:     "%s sideboards may have at most %d cards. Current: %d",
+		CodeMissingCardID:         "%s has no card ID",
+		CodeTooManyCommanders:     "Commander decks may have at most 2 commanders. Current: %d",
+		CodeIncompatiblePair:      "%s (%s) and %s (%s) cannot be paired as commanders",
+		CodeMissingDeckName:       "Deck has no name",
+		CodeDeckNameTooLong:       "Deck name is %d characters long; the limit is %d",
+		CodeDeckNameInvalid:       "Deck name contains control characters",
+		CodeUnknownBracket:        "Unknown Commander bracket %d",
+		CodeBracketCardBanned:     "%s is not allowed in bracket %s",
+		CodeGameChangerLimit:      "Deck has %d game changers, more than bracket %s allows (%d): %s",
+		CodeSingletonDuplicates:   "Deck has only %d unique nonbasic cards for %d nonbasic copies; some cards may be duplicated under different names",
+		CodeRestrictedCard:        "%s is restricted to one copy in %s. Current: %d",
+		CodeColorIdentity:         "%s has colors %s outside the commanders' color identity %s",
+		CodeOathbreakerCount:      "Oathbreaker decks need exactly one oathbreaker. Current: %d",
+		CodeSignatureSpellCount:   "Oathbreaker decks need exactly one signature spell. Current: %d",
+		CodeNotPlaneswalker:       "Oathbreaker %s is not a planeswalker",
+		CodeNotInstantOrSorcery:   "Signature spell %s is not an instant or sorcery",
+		CodeSignatureIdentity:     "Signature spell %s has colors %s outside the oathbreaker's color identity %s",
+		CodeSideboardBannedCard:   "Sideboard: %s is banned in %s",
+		CodeSideboardCopyLimit:    "Sideboard: %s exceeds the copy limit of %d across main deck and sideboard. Current: %d",
+		CodeUnknownGame:           "Unknown game '%s'; only general checks were run",
+		CodeGameNotAllowed:        "Game '%s' is not accepted here. Allowed: %s",
+		CodeTagNotAllowed:         "Tag '%s' is not in the allowed tag list",
+		CodeMissingGame:           "Deck has no game",
+		CodeInvalidGame:           "Game '%s' must be lower-case letters, digits and hyphens",
+		CodeEmptyDeck:             "Deck is empty",
+		CodeUnidentifiedCard:      "A card has neither a name nor an ID",
+		CodeInvalidCount:          "%s has count %d; counts must be positive",
+		CodeInvalidTimestamp:      "Metadata %s '%s' is not a recognized timestamp",
+		CodeHeaderOverride:        "Header %s overrides %s=%d for this request",
+		CodeHeaderOverrideInvalid: "Header %s has invalid value %q and was ignored",
+		CodeResourceCount:         "Deck has %d %s; at least %d are recommended",
+		CodeUnknownCardSuggest:    "Unknown card '%s'; did you mean '%s'?",
+		CodeRarityLimitExceeded:   "%s is limited to %d copies as a %s card. Current: %d",
+		CodeMissingRarity:         "%s has no rarity; the default limit of %d copies applies",
+		CodeThemeConsistency:      "Only %d%% of nonland cards match the %s theme; at least %d%% are recommended",
+		CodePlaneswalkerUnique:    "%s has %d copies; under the old planeswalker uniqueness rule only one can be in play",
+		CodePlaygroupBanned:       "%s is banned by your playgroup",
+		CodeWrongGameCard:         "%s is a card from %s, not %s",
+		CodeBannedCombo:           "%s may not be played together in %s",
+	},
+	"de": {
+		CodeDeckSizeMismatch:      "%s-Decks müssen genau %d Karten enthalten. Aktuell: %d",
+		CodeDeckSizeTooSmall:      "%s-Decks müssen mindestens %d Karten enthalten. Aktuell: %d",
+		CodeCopyLimitExceeded:     "%s überschreitet das Kopienlimit von %d. Aktuell: %d",
+		CodeSetNotLegal:           "%s stammt aus der Edition %s, die in %s nicht legal ist",
+		CodeSetUnknown:            "Die Editionslegalität konnte für Karten ohne Editionsangabe nicht geprüft werden: %d",
+		CodeMissingLegend:         "Keine Legende ausgewählt",
+		CodeMissingBattlefield:    "Kein Schlachtfeld ausgewählt",
+		CodeBattlefieldCount:      "Riftbound-Decks müssen genau %d Schlachtfelder enthalten. Aktuell: %d",
+		CodeDuplicateBattlefield:  "Schlachtfeld %s ist mehrfach enthalten",
+		CodeRuneCount:             "Riftbound-Runendecks müssen genau %d Runen enthalten. Aktuell: %d",
+		CodeConflictingGameFields: "Das Deck enthält sowohl eine Riftbound-Legende als auch MTG-Kommandeure",
+		CodeForeignZoneIgnored:    "Das MTG-Deck enthält Riftbound-Legende, -Schlachtfeld oder -Runen, die ignoriert werden",
+		CodeSideboardNotAllowed:   "%s-Decks dürfen kein Sideboard haben",
+		CodeSideboardMerged:       "Deck-Sideboard und sideboard-Parameter waren beide vorhanden und wurden zusammengeführt",
+		CodeCommandersIgnored:     "Kommandeure werden in %s-Decks ignoriert",
+		CodeCommandersNotAllowed:  "%s-Decks dürfen keine Kommandeure haben",
+		CodeCompanionNotAllowed:   "%s-Decks dürfen keinen Gefährten haben",
+		CodeCompanionUncheckable:  "Die Gefährten-Einschränkung für %s kann nicht geprüft werden",
+		CodeCompanionViolation:    "%s verstößt gegen die Gefährten-Einschränkung von %s: %s",
+		CodeCompanionUnchecked:    "Karten ohne Typangabe konnten nicht gegen die Gefährten-Einschränkung von %s geprüft werden: %d",
+		CodeNoWinCondition:        "Das Deck enthält keine Kreaturen, Planeswalker oder bekannten alternativen Siegbedingungen",
+		CodeTooManyWarnings:       "Das Deck hat %d Warnungen, mehr als die erlaubten %d",
+		CodeOverrideInEffect:      "Überschreibung %s=%d ist aktiv",
+		CodeOverrideUnknown:       "Unbekannte Überschreibung %q ignoriert",
+		CodeOverrideInvalid:       "Überschreibung %s hat den ungültigen Wert %q und wurde ignoriert",
+		CodeUnknownCardID:         "Unbekannte Karten-ID %s",
+		CodeUnknownCardName:       "Unbekannte Karte %s",
+		CodePointsCapExceeded:     "Das Deck hat %d Punkte, mehr als die erlaubten %d: %s",
+		CodePoolCardMissing:       "%s ist nicht im Pool",
+		CodePoolCopiesExceeded:    "%s wird %d-mal verwendet, der Pool enthält aber nur %d",
+		CodeUnknownFormat:         "Unbekanntes MTG-Format %q; nur allgemeine Prüfungen wurden durchgeführt",
+		CodeFormatAliased:         "Format %q wurde als %q behandelt",
+		CodeAuthorMismatch:        "Deck-Autor %q stimmt nicht mit dem angemeldeten Benutzer %q überein",
+		CodeLandCount:             "Das Deck hat %d Länder; empfohlen sind %d-%d",
+		CodeNotInCube:             "%s ist nicht im Cube",
+		CodeBannedCard:            "%s ist in %s gebannt",
+		CodeSideboardTooLarge:     "%s-Sideboards dürfen höchstens %d Karten enthalten. Aktuell: %d",
+		CodeMissingCardID:         "%s hat keine Karten-ID",
+		CodeTooManyCommanders:     "Commander-Decks dürfen höchstens 2 Kommandeure haben. Aktuell: %d",
+		CodeIncompatiblePair:      "%s (%s) und %s (%s) können nicht gemeinsam Kommandeure sein",
+		CodeMissingDeckName:       "Das Deck hat keinen Namen",
+		CodeDeckNameTooLong:       "Der Deckname ist %d Zeichen lang; erlaubt sind %d",
+		CodeDeckNameInvalid:       "Der Deckname enthält Steuerzeichen",
+		CodeUnknownBracket:        "Unbekannte Commander-Stufe %d",
+		CodeBracketCardBanned:     "%s ist in Stufe %s nicht erlaubt",
+		CodeGameChangerLimit:      "Das Deck hat %d Game Changer, mehr als Stufe %s erlaubt (%d): %s",
+		CodeSingletonDuplicates:   "Das Deck hat nur %d verschiedene Nicht-Standardkarten bei %d Exemplaren; manche Karten sind eventuell unter anderen Namen doppelt",
+		CodeRestrictedCard:        "%s ist in %s auf ein Exemplar beschränkt. Aktuell: %d",
+		CodeColorIdentity:         "%s hat die Farben %s außerhalb der Farbidentität %s der Kommandeure",
+		CodeOathbreakerCount:      "Oathbreaker-Decks brauchen genau einen Oathbreaker. Aktuell: %d",
+		CodeSignatureSpellCount:   "Oathbreaker-Decks brauchen genau einen Signaturzauber. Aktuell: %d",
+		CodeNotPlaneswalker:       "Oathbreaker %s ist kein Planeswalker",
+		CodeNotInstantOrSorcery:   "Signaturzauber %s ist weder Spontanzauber noch Hexerei",
+		CodeSignatureIdentity:     "Signaturzauber %s hat die Farben %s außerhalb der Farbidentität %s des Oathbreakers",
+		CodeSideboardBannedCard:   "Sideboard: %s ist in %s gebannt",
+		CodeSideboardCopyLimit:    "Sideboard: %s überschreitet das Kopienlimit von %d in Deck und Sideboard zusammen. Aktuell: %d",
+		CodeUnknownGame:           "Unbekanntes Spiel '%s'; nur allgemeine Prüfungen wurden ausgeführt",
+		CodeGameNotAllowed:        "Spiel '%s' wird hier nicht akzeptiert. Erlaubt: %s",
+		CodeTagNotAllowed:         "Tag '%s' ist nicht in der Liste erlaubter Tags",
+		CodeMissingGame:           "Deck hat kein Spiel",
+		CodeInvalidGame:           "Spiel '%s' darf nur Kleinbuchstaben, Ziffern und Bindestriche enthalten",
+		CodeEmptyDeck:             "Das Deck ist leer",
+		CodeUnidentifiedCard:      "Eine Karte hat weder Namen noch ID",
+		CodeInvalidCount:          "%s hat die Anzahl %d; Anzahlen müssen positiv sein",
+		CodeInvalidTimestamp:      "Metadaten-Feld %s '%s' ist kein erkannter Zeitstempel",
+		CodeHeaderOverride:        "Header %s überschreibt %s=%d für diese Anfrage",
+		CodeHeaderOverrideInvalid: "Header %s hat den ungültigen Wert %q und wurde ignoriert",
+		CodeResourceCount:         "Das Deck hat %d %s; empfohlen sind mindestens %d",
+		CodeUnknownCardSuggest:    "Unbekannte Karte '%s'; meinten Sie '%s'?",
+		CodeRarityLimitExceeded:   "%s ist auf %d Exemplare begrenzt (Seltenheit %s). Aktuell: %d",
+		CodeMissingRarity:         "%s hat keine Seltenheit; es gilt das Standardlimit von %d Exemplaren",
+		CodeThemeConsistency:      "Nur %d%% der Nichtland-Karten passen zum Thema %s; empfohlen sind mindestens %d%%",
+		CodePlaneswalkerUnique:    "%s hat %d Exemplare; nach der alten Planeswalker-Regel kann nur eines im Spiel sein",
+		CodePlaygroupBanned:       "%s ist in Ihrer Spielgruppe gebannt",
+		CodeWrongGameCard:         "%s ist eine Karte aus %s, nicht aus %s",
+		CodeBannedCombo:           "%s dürfen in %s nicht zusammen gespielt werden",
+	},
+	"fr": {
+		CodeDeckSizeMismatch:      "Les decks %s doivent contenir exactement %d cartes. Actuellement : %d",
+		CodeDeckSizeTooSmall:      "Les decks %s doivent contenir au moins %d cartes. Actuellement : %d",
+		CodeCopyLimitExceeded:     "%s dépasse la limite de %d exemplaires. Actuellement : %d",
+		CodeSetNotLegal:           "%s provient de l'extension %s, qui n'est pas légale en %s",
+		CodeSetUnknown:            "La légalité de l'extension n'a pas pu être vérifiée pour les cartes sans extension : %d",
+		CodeMissingLegend:         "Aucune légende sélectionnée",
+		CodeMissingBattlefield:    "Aucun champ de bataille sélectionné",
+		CodeBattlefieldCount:      "Les decks Riftbound doivent contenir exactement %d champs de bataille. Actuellement : %d",
+		CodeDuplicateBattlefield:  "Le champ de bataille %s est inclus plusieurs fois",
+		CodeRuneCount:             "Les decks de runes Riftbound doivent contenir exactement %d runes. Actuellement : %d",
+		CodeConflictingGameFields: "Le deck déclare à la fois une légende Riftbound et des commandants MTG",
+		CodeForeignZoneIgnored:    "Le deck MTG contient une légende, un champ de bataille ou des runes Riftbound, qui seront ignorés",
+		CodeSideboardNotAllowed:   "Les decks %s ne peuvent pas avoir de réserve",
+		CodeSideboardMerged:       "La réserve du deck et le paramètre sideboard étaient tous deux présents et ont été fusionnés",
+		CodeCommandersIgnored:     "Les commandants sont ignorés dans les decks %s",
+		CodeCommandersNotAllowed:  "Les decks %s ne peuvent pas avoir de commandants",
+		CodeCompanionNotAllowed:   "Les decks %s ne peuvent pas avoir de compagnon",
+		CodeCompanionUncheckable:  "La restriction de compagnon de %s ne peut pas être vérifiée",
+		CodeCompanionViolation:    "%s enfreint la restriction de compagnon de %s : %s",
+		CodeCompanionUnchecked:    "Les cartes sans type n'ont pas pu être vérifiées selon la restriction de compagnon de %s : %d",
+		CodeNoWinCondition:        "Le deck ne contient ni créatures, ni planeswalkers, ni conditions de victoire alternatives connues",
+		CodeTooManyWarnings:       "Le deck a %d avertissements, plus que les %d autorisés",
+		CodeOverrideInEffect:      "La dérogation %s=%d est active",
+		CodeOverrideUnknown:       "Dérogation inconnue %q ignorée",
+		CodeOverrideInvalid:       "La dérogation %s a la valeur invalide %q et a été ignorée",
+		CodeUnknownCardID:         "Identifiant de carte inconnu %s",
+		CodeUnknownCardName:       "Carte inconnue %s",
+		CodePointsCapExceeded:     "Le deck a %d points, plus que les %d autorisés : %s",
+		CodePoolCardMissing:       "%s ne fait pas partie du pool",
+		CodePoolCopiesExceeded:    "%s est utilisé %d fois mais le pool n'en contient que %d",
+		CodeUnknownFormat:         "Format MTG inconnu %q ; seules les vérifications générales ont été appliquées",
+		CodeFormatAliased:         "Le format %q a été traité comme %q",
+		CodeAuthorMismatch:        "L'auteur du deck %q ne correspond pas à l'utilisateur authentifié %q",
+		CodeLandCount:             "Le deck contient %d terrains ; %d à %d sont recommandés",
+		CodeNotInCube:             "%s ne fait pas partie du cube",
+		CodeBannedCard:            "%s est bannie en %s",
+		CodeSideboardTooLarge:     "Les sideboards %s peuvent contenir au plus %d cartes. Actuellement : %d",
+		CodeMissingCardID:         "%s n'a pas d'identifiant de carte",
+		CodeTooManyCommanders:     "Les decks Commander peuvent avoir au plus 2 commandants. Actuellement : %d",
+		CodeIncompatiblePair:      "%s (%s) et %s (%s) ne peuvent pas être associés comme commandants",
+		CodeMissingDeckName:       "Le deck n'a pas de nom",
+		CodeDeckNameTooLong:       "Le nom du deck fait %d caractères ; la limite est de %d",
+		CodeDeckNameInvalid:       "Le nom du deck contient des caractères de contrôle",
+		CodeUnknownBracket:        "Tranche Commander inconnue %d",
+		CodeBracketCardBanned:     "%s n'est pas autorisée dans la tranche %s",
+		CodeGameChangerLimit:      "Le deck contient %d game changers, plus que ce que la tranche %s autorise (%d) : %s",
+		CodeSingletonDuplicates:   "Le deck n'a que %d cartes non basiques uniques pour %d exemplaires ; certaines cartes sont peut-être en double sous d'autres noms",
+		CodeRestrictedCard:        "%s est limitée à un exemplaire en %s. Actuellement : %d",
+		CodeColorIdentity:         "%s a les couleurs %s hors de l'identité couleur %s des commandants",
+		CodeOathbreakerCount:      "Les decks Oathbreaker exigent exactement un oathbreaker. Actuellement : %d",
+		CodeSignatureSpellCount:   "Les decks Oathbreaker exigent exactement un sort signature. Actuellement : %d",
+		CodeNotPlaneswalker:       "L'oathbreaker %s n'est pas un planeswalker",
+		CodeNotInstantOrSorcery:   "Le sort signature %s n'est ni un éphémère ni un rituel",
+		CodeSignatureIdentity:     "Le sort signature %s a les couleurs %s hors de l'identité couleur %s de l'oathbreaker",
+		CodeSideboardBannedCard:   "Sideboard : %s est bannie en %s",
+		CodeSideboardCopyLimit:    "Sideboard : %s dépasse la limite de %d exemplaires entre le deck et le sideboard. Actuellement : %d",
+		CodeUnknownGame:           "Jeu inconnu '%s' ; seules les vérifications générales ont été effectuées",
+		CodeGameNotAllowed:        "Le jeu '%s' n'est pas accepté ici. Autorisés : %s",
+		CodeTagNotAllowed:         "Le tag '%s' n'est pas dans la liste des tags autorisés",
+		CodeMissingGame:           "Le deck n'a pas de jeu",
+		CodeInvalidGame:           "Le jeu '%s' ne doit contenir que des minuscules, des chiffres et des tirets",
+		CodeEmptyDeck:             "Le deck est vide",
+		CodeUnidentifiedCard:      "Une carte n'a ni nom ni ID",
+		CodeInvalidCount:          "%s a une quantité de %d ; les quantités doivent être positives",
+		CodeInvalidTimestamp:      "Le champ de métadonnées %s '%s' n'est pas une date reconnue",
+		CodeHeaderOverride:        "L'en-tête %s remplace %s=%d pour cette requête",
+		CodeHeaderOverrideInvalid: "L'en-tête %s a la valeur invalide %q et a été ignoré",
+		CodeResourceCount:         "Le deck contient %d %s ; au moins %d sont recommandés",
+		CodeUnknownCardSuggest:    "Carte inconnue '%s' ; vouliez-vous dire '%s' ?",
+		CodeRarityLimitExceeded:   "%s est limitée à %d exemplaires en tant que carte %s. Actuellement : %d",
+		CodeMissingRarity:         "%s n'a pas de rareté ; la limite par défaut de %d exemplaires s'applique",
+		CodeThemeConsistency:      "Seules %d %% des cartes non-terrain correspondent au thème %s ; au moins %d %% sont recommandées",
+		CodePlaneswalkerUnique:    "%s a %d exemplaires ; selon l'ancienne règle d'unicité des planeswalkers, un seul peut être en jeu",
+		CodePlaygroupBanned:       "%s est bannie par votre groupe de jeu",
+		CodeWrongGameCard:         "%s est une carte de %s, pas de %s",
+		CodeBannedCombo:           "%s ne peuvent pas être jouées ensemble en %s",
+	},
+}
+
+// renderMessage formats an issue message in locale.
+func renderMessage(locale, code string, args []interface{}) string {
+	tmpl, ok := messages[locale][code]
+	if !ok {
+		tmpl, ok = messages[defaultLocale][code]
+	}
+	if !ok {
+		return code
+	}
+	return fmt.Sprintf(tmpl, args...)
+}
+
+// negotiateLocale picks the supported locale the client prefers most from
+// an Accept-Language header, falling back to English.
+func negotiateLocale(header string) string {
+	type pref struct {
+		lang string
+		q    float64
+	}
+	var prefs []pref
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		lang := strings.ToLower(strings.TrimSpace(fields[0]))
+		if i := strings.IndexByte(lang, '-'); i >= 0 {
+			lang = lang[:i]
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					q = f
+				}
+			}
+		}
+		prefs = append(prefs, pref{lang, q})
+	}
+	sort.SliceStable(prefs, func(i, j int) bool { return prefs[i].q > prefs[j].q })
+
+	for _, p := range prefs {
+		if _, ok := messages[p.lang]; ok && p.q > 0 {
+			return p.lang
+		}
+	}
+	return defaultLocale
+}
+
+// localize re-renders every issue message in locale and rebuilds the
+// legacy Errors and Warnings lists from the issues.
+func (r *ValidationResult) localize(locale string) {
+	r.Errors = []string{}
+	r.Warnings = []string{}
+	for i := range r.Issues {
+		issue := &r.Issues[i]
+		issue.Message = renderMessage(locale, issue.Code, issue.args)
+		switch issue.Severity {
+		case SeverityError:
+			r.Errors = append(r.Errors, issue.Message)
+		case SeverityWarning:
+			r.Warnings = append(r.Warnings, issue.Message)
+		}
+	}
+}
+
+// localizeResult renders the result in the locale requested by r's
+// Accept-Language header and sets Content-Language to match.
+func localizeResult(w http.ResponseWriter, r *http.Request, result *ValidationResult) {
+	locale := negotiateLocale(r.Header.Get("Accept-Language"))
+	result.localize(locale)
+	w.Header().Set("Content-Language", locale)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// mtgoDeck mirrors MTGO's .dek deck file.
+type mtgoDeck struct {
+	XMLName              xml.Name   `xml:"Deck"`
+	NetDeckID            int        `xml:"NetDeckID"`
+	PreconstructedDeckID int        `xml:"PreconstructedDeckID"`
+	Cards                []mtgoCard `xml:"Cards"`
+}
+
+type mtgoCard struct {
+	CatID     string `xml:"CatID,attr,omitempty"`
+	Quantity  int    `xml:"Quantity,attr"`
+	Sideboard bool   `xml:"Sideboard,attr"`
+	Name      string `xml:"Name,attr"`
+}
+
+// exportMTGODek renders the deck as an MTGO .dek file. MTGO identifies
+// cards by catalog ID, taken from each card's ID; see mtgoDekWarnings for
+// cards that lack one.
+func exportMTGODek(deck *Deck) (string, error) {
+	dek := mtgoDeck{}
+	for _, card := range deck.Cards {
+		dek.Cards = append(dek.Cards, mtgoCard{CatID: card.ID, Quantity: card.Count, Name: displayName(card)})
+	}
+	for _, card := range deck.Sideboard {
+		dek.Cards = append(dek.Cards, mtgoCard{CatID: card.ID, Quantity: card.Count, Sideboard: true, Name: displayName(card)})
+	}
+
+	out, err := xml.MarshalIndent(dek, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(out) + "\n", nil
+}
+
+// mtgoDekWarnings lists the cards exported without a catalog ID, which MTGO
+// may fail to import.
+func mtgoDekWarnings(deck *Deck) []string {
+	var warnings []string
+	for _, card := range append(append([]DeckCard{}, deck.Cards...), deck.Sideboard...) {
+		if card.ID == "" {
+			warnings = append(warnings, fmt.Sprintf("%s has no ID to use as its MTGO CatID", displayName(card)))
+		}
+	}
+	return warnings
+}
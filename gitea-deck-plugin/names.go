@@ -0,0 +1,161 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// NameNormalization controls how card names are folded before they are
+// compared, so that variant spellings count as the same card.
+type NameNormalization struct {
+	FoldCase bool `json:"foldCase"`
+	// Replacements maps a substring to the text that replaces it, e.g.
+	// "Æ" -> "Ae" or "û" -> "u".
+	Replacements map[string]string `json:"replacements,omitempty"`
+}
+
+func defaultNameNormalization() NameNormalization {
+	return NameNormalization{
+		FoldCase: true,
+		Replacements: map[string]string{
+			"Æ": "Ae", "æ": "ae", "Œ": "Oe", "œ": "oe", "ß": "ss",
+			"À": "A", "Á": "A", "Â": "A", "Ã": "A", "Ä": "A", "Å": "A",
+			"à": "a", "á": "a", "â": "a", "ã": "a", "ä": "a", "å": "a",
+			"Ç": "C", "ç": "c",
+			"È": "E", "É": "E", "Ê": "E", "Ë": "E",
+			"è": "e", "é": "e", "ê": "e", "ë": "e",
+			"Ì": "I", "Í": "I", "Î": "I", "Ï": "I",
+			"ì": "i", "í": "i", "î": "i", "ï": "i",
+			"Ñ": "N", "ñ": "n",
+			"Ò": "O", "Ó": "O", "Ô": "O", "Õ": "O", "Ö": "O", "Ø": "O",
+			"ò": "o", "ó": "o", "ô": "o", "õ": "o", "ö": "o", "ø": "o",
+			"Ù": "U", "Ú": "U", "Û": "U", "Ü": "U",
+			"ù": "u", "ú": "u", "û": "u", "ü": "u",
+			"Ý": "Y", "ý": "y", "ÿ": "y",
+			"‘": "'", "’": "'", "`": "'",
+			"“": "\"", "”": "\"",
+			"–": "-", "—": "-",
+		},
+	}
+}
+
+// normalizeName folds a card name using the normalization rules for game.
+// Whitespace runs are always collapsed to a single space.
+func normalizeName(game, name string) string {
+	key := game
+	n, ok := rules.NameNormalization[game]
+	if !ok {
+		key = "*"
+		n = rules.NameNormalization["*"]
+	}
+
+	s := strings.Join(strings.Fields(name), " ")
+	if len(n.Replacements) > 0 {
+		replacer, ok := rules.nameReplacers[key]
+		if !ok {
+			replacer = n.replacer()
+		}
+		s = replacer.Replace(s)
+	}
+	if n.FoldCase {
+		s = strings.ToLower(s)
+	}
+	return s
+}
+
+// replacer builds the strings.Replacer for n.Replacements.
+func (n NameNormalization) replacer() *strings.Replacer {
+	// Longer patterns go first so they win over any shorter pattern that
+	// is a prefix of them.
+	keys := make([]string, 0, len(n.Replacements))
+	for k := range n.Replacements {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	pairs := make([]string, 0, len(keys)*2)
+	for _, k := range keys {
+		pairs = append(pairs, k, n.Replacements[k])
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// compileNameReplacers builds the replacer for every NameNormalization
+// entry, so normalizeName doesn't rebuild one per call. It must be called
+// whenever NameNormalization changes.
+func (r *Rules) compileNameReplacers() {
+	r.nameReplacers = map[string]*strings.Replacer{}
+	for game, n := range r.NameNormalization {
+		if len(n.Replacements) > 0 {
+			r.nameReplacers[game] = n.replacer()
+		}
+	}
+}
+
+// cardKey returns the key a card is grouped under for copy counting: its
+// normalized name, or its ID when the entry has no name.
+func cardKey(game string, card DeckCard) string {
+	if card.Name == "" {
+		return card.ID
+	}
+	return normalizeName(game, card.Name)
+}
+
+// cardFaces returns the names of a card's faces: Faces, or the parts of a
+// "Fire // Ice" name. It is nil for single-faced cards.
+func cardFaces(card DeckCard) []string {
+	if len(card.Faces) > 0 {
+		return card.Faces
+	}
+	if strings.Contains(card.Name, " // ") {
+		return strings.Split(card.Name, " // ")
+	}
+	return nil
+}
+
+// allNames returns a card's full name followed by the name of each face.
+func allNames(card DeckCard) []string {
+	var names []string
+	if card.Name != "" {
+		names = append(names, card.Name)
+	}
+	for _, face := range cardFaces(card) {
+		if face = strings.TrimSpace(face); face != "" && face != card.Name {
+			names = append(names, face)
+		}
+	}
+	return names
+}
+
+// faceKey is like cardKey but keys multi-face cards by their front face, so
+// "Fire // Ice" and "Fire" are counted as the same card.
+func faceKey(game string, card DeckCard) string {
+	if faces := cardFaces(card); len(faces) > 0 && strings.TrimSpace(faces[0]) != "" {
+		return normalizeName(game, strings.TrimSpace(faces[0]))
+	}
+	return cardKey(game, card)
+}
+
+// arenaRebalancedName returns the name of the card an Arena rebalanced
+// card is based on, "Luminarch Aspirant" for "A-Luminarch Aspirant". Other
+// names are returned unchanged.
+func arenaRebalancedName(name string) string {
+	name = strings.TrimSpace(name)
+	if base, ok := strings.CutPrefix(name, "A-"); ok && base != "" {
+		return base
+	}
+	return name
+}
+
+// displayName is the name used for a card in messages: its name, or its ID
+// when the entry has no name.
+func displayName(card DeckCard) string {
+	if card.Name == "" {
+		return card.ID
+	}
+	return card.Name
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		game, a, b string
+		same       bool
+	}{
+		{"mtg", "Lim-Dûl's Vault", "Lim-Dul's Vault", true},
+		{"mtg", "Æther Vial", "Aether Vial", true},
+		{"mtg", "Jötun Grunt", "jotun grunt", true},
+		{"mtg", "Urza’s Saga", "Urza's Saga", true},
+		{"mtg", "  Sol   Ring ", "Sol Ring", true},
+		{"riftbound", "Déjà Vu", "Deja Vu", true},
+		{"mtg", "Sol Ring", "Sol Talisman", false},
+		{"mtg", "Lim-Dûl's Vault", "Lim-Dul Vault", false},
+	}
+	for _, tt := range tests {
+		a, b := normalizeName(tt.game, tt.a), normalizeName(tt.game, tt.b)
+		if (a == b) != tt.same {
+			t.Errorf("normalizeName(%q, %q) = %q and (%q) = %q, same = %v, want %v", tt.game, tt.a, a, tt.b, b, a == b, tt.same)
+		}
+	}
+}
+
+func TestCopyLimitWithVariantSpellings(t *testing.T) {
+	deck := &Deck{Game: "mtg", Format: "modern", Cards: []DeckCard{
+		{Name: "Lim-Dûl's Vault", Count: 3},
+		{Name: "Lim-Dul's Vault", Count: 2},
+	}}
+	var result ValidationResult
+	checkCopyLimit(deck, 4, &result)
+	if !hasIssue(result, CodeCopyLimitExceeded, "Lim-Dûl's Vault") {
+		t.Errorf("3 + 2 copies under two spellings: got errors %v, want a copy limit error", result.Errors)
+	}
+}
+
+func TestNameNormalizationPerGame(t *testing.T) {
+	saved := rules
+	defer func() { rules = saved }()
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	config := `{"nameNormalization": {
+		"riftbound": {"foldCase": false, "replacements": {"Ū": "U"}},
+		"mtg": {"foldCase": true, "replacements": {"&": "and"}}
+	}}`
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules = loaded
+
+	tests := []struct {
+		game, name, want string
+	}{
+		{"riftbound", "Ūrsa Major", "Ursa Major"},
+		{"riftbound", "déjà", "déjà"},
+		{"mtg", "Salt & Pepper", "salt and pepper"},
+		{"mtg", "Æther Vial", "æther vial"},
+		// Games without their own entry use "*".
+		{"lorcana", "Æther Vial", "aether vial"},
+	}
+	for _, tt := range tests {
+		if got := normalizeName(tt.game, tt.name); got != tt.want {
+			t.Errorf("normalizeName(%q, %q) = %q, want %q", tt.game, tt.name, got, tt.want)
+		}
+	}
+	for game := range loaded.NameNormalization {
+		if _, ok := loaded.nameReplacers[game]; !ok {
+			t.Errorf("no compiled replacer for %q", game)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+// checkOathbreaker checks an Oathbreaker deck's command zone: exactly one
+// oathbreaker, which must be a planeswalker, and exactly one signature
+// spell, which must be an instant or sorcery within the oathbreaker's color
+// identity. Type and color checks are skipped for cards without that data.
+func checkOathbreaker(deck *Deck, result *ValidationResult) {
+	if n := commandZoneCount(deck.Oathbreaker); n != 1 {
+		result.addError(CodeOathbreakerCount, "", n)
+	}
+	if n := commandZoneCount(deck.SignatureSpell); n != 1 {
+		result.addError(CodeSignatureSpellCount, "", n)
+	}
+
+	if ob := deck.Oathbreaker; ob != nil && ob.Type != "" && !hasType(*ob, "planeswalker") {
+		result.addError(CodeNotPlaneswalker, displayName(*ob), displayName(*ob))
+	}
+	spell := deck.SignatureSpell
+	if spell == nil {
+		return
+	}
+	if spell.Type != "" && !hasType(*spell, "instant") && !hasType(*spell, "sorcery") {
+		result.addError(CodeNotInstantOrSorcery, displayName(*spell), displayName(*spell))
+	}
+
+	if deck.Oathbreaker == nil {
+		return
+	}
+	identity, ok := colorIdentity([]DeckCard{*deck.Oathbreaker})
+	if !ok {
+		return
+	}
+	if outside := outsideIdentity(*spell, identity); outside != "" {
+		result.addError(CodeSignatureIdentity, displayName(*spell), displayName(*spell), outside, identityString(identity))
+	}
+}
+
+// commandZoneCount is the number of copies of a single-card command zone
+// entry. An entry without a count is one copy.
+func commandZoneCount(card *DeckCard) int {
+	switch {
+	case card == nil:
+		return 0
+	case card.Count == 0:
+		return 1
+	default:
+		return card.Count
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+)
+
+// deckOverrides are house-rule adjustments to the format's limits. Zero
+// means the format default applies.
+type deckOverrides struct {
+	DeckSize  int
+	MaxCopies int
+}
+
+func (o deckOverrides) deckSize(def int) int {
+	if o.DeckSize > 0 {
+		return o.DeckSize
+	}
+	return def
+}
+
+func (o deckOverrides) maxCopies(def int) int {
+	if o.MaxCopies > 0 {
+		return o.MaxCopies
+	}
+	return def
+}
+
+// overrideHeaders maps the request headers that override a limit for one
+// validation to the Metadata.Overrides key they correspond to.
+var overrideHeaders = map[string]string{
+	"X-Deck-Size":       "deckSize",
+	"X-Deck-Max-Copies": "maxCopies",
+}
+
+// readOverrides parses the deck's Metadata.Overrides, then the request's
+// override headers, which win over the deck's. Every override in effect is
+// reported as a warning; unknown keys and malformed values are warned about
+// and ignored.
+func readOverrides(deck *Deck, opts ValidateOptions, result *ValidationResult) deckOverrides {
+	var o deckOverrides
+
+	keys := make([]string, 0, len(deck.Metadata.Overrides))
+	for k := range deck.Metadata.Overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := deck.Metadata.Overrides[key]
+		var target *int
+		switch key {
+		case "deckSize":
+			target = &o.DeckSize
+		case "maxCopies":
+			target = &o.MaxCopies
+		default:
+			result.addWarning(CodeOverrideUnknown, "", key)
+			continue
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			result.addWarning(CodeOverrideInvalid, "", key, value)
+			continue
+		}
+		*target = n
+		result.addWarning(CodeOverrideInEffect, "", key, n)
+	}
+
+	headers := make([]string, 0, len(opts.HeaderOverrides))
+	for h := range opts.HeaderOverrides {
+		headers = append(headers, h)
+	}
+	sort.Strings(headers)
+	for _, header := range headers {
+		value := opts.HeaderOverrides[header]
+		target := &o.DeckSize
+		if overrideHeaders[header] == "maxCopies" {
+			target = &o.MaxCopies
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			result.addWarning(CodeHeaderOverrideInvalid, "", header, value)
+			continue
+		}
+		*target = n
+		result.addWarning(CodeHeaderOverride, "", header, overrideHeaders[header], n)
+	}
+	return o
+}
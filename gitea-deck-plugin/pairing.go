@@ -0,0 +1,50 @@
+package main
+
+import "strings"
+
+// pairPartners maps each commander pair type to the pair type its partner
+// must have. "Partner with" is handled separately because it names a
+// specific card.
+var pairPartners = map[string]string{
+	"partner":             "partner",
+	"friends-forever":     "friends-forever",
+	"doctors-companion":   "doctor",
+	"doctor":              "doctors-companion",
+	"choose-a-background": "background",
+	"background":          "choose-a-background",
+}
+
+// partnerWithPrefix starts the pair type of a "Partner with" commander,
+// followed by the name of the card it partners with.
+const partnerWithPrefix = "partner-with:"
+
+// pairsWith reports whether commander a's pair type allows b as its
+// partner.
+func pairsWith(game string, a, b DeckCard) bool {
+	if target, ok := strings.CutPrefix(a.PairType, partnerWithPrefix); ok {
+		return normalizeName(game, target) == cardKey(game, b)
+	}
+	want, ok := pairPartners[a.PairType]
+	return ok && want == b.PairType
+}
+
+// checkCommanderPair validates a Commander deck's commanders: at most two,
+// and a pair must have compatible pair types in both directions. Pairs where
+// either card has no pairType can't be checked and are accepted.
+func checkCommanderPair(deck *Deck, result *ValidationResult) {
+	cmdrs := commandersOf(deck)
+	if len(cmdrs) > 2 {
+		result.addError(CodeTooManyCommanders, "", len(cmdrs))
+		return
+	}
+	if len(cmdrs) != 2 {
+		return
+	}
+	a, b := cmdrs[0], cmdrs[1]
+	if a.PairType == "" || b.PairType == "" {
+		return
+	}
+	if !pairsWith(deck.Game, a, b) || !pairsWith(deck.Game, b, a) {
+		result.addError(CodeIncompatiblePair, "", displayName(a), a.PairType, displayName(b), b.PairType)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+// validatePool validates a sealed or draft pool. The whole pool is in Cards
+// and has no size requirement; the deck built from it, if any, is in
+// Sideboard and must have at least 40 cards, all drawn from the pool. Basic
+// lands are always available and don't need to be in the pool.
+func validatePool(deck *Deck) ValidationResult {
+	result := newValidationResult()
+	if len(deck.Sideboard) == 0 {
+		return result
+	}
+
+	pool := map[string]int{}
+	for _, card := range deck.Cards {
+		pool[cardKey(deck.Game, card)] += card.Count
+	}
+
+	built := 0
+	used := map[string]int{}
+	var order []DeckCard
+	for _, card := range deck.Sideboard {
+		built += card.Count
+		if isBasicLand(card.Name) {
+			continue
+		}
+		key := cardKey(deck.Game, card)
+		if _, seen := used[key]; !seen {
+			order = append(order, card)
+		}
+		used[key] += card.Count
+	}
+
+	checkMinSize("Pool", 40, built, &result)
+	for _, card := range order {
+		key := cardKey(deck.Game, card)
+		switch have := pool[key]; {
+		case have == 0:
+			result.addError(CodePoolCardMissing, displayName(card), displayName(card))
+		case used[key] > have:
+			result.addError(CodePoolCopiesExceeded, displayName(card), displayName(card), used[key], have)
+		}
+	}
+	return result
+}
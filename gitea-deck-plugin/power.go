@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// PowerCards lists the card names the power estimate looks for.
+type PowerCards struct {
+	FastMana []string `json:"fastMana"`
+	Tutors   []string `json:"tutors"`
+	Combo    []string `json:"combo"`
+}
+
+func defaultPowerCards() PowerCards {
+	return PowerCards{
+		FastMana: []string{
+			"Sol Ring", "Mana Crypt", "Mana Vault", "Chrome Mox", "Mox Diamond",
+			"Mox Opal", "Jeweled Lotus", "Lotus Petal", "Grim Monolith",
+			"Ancient Tomb", "Dark Ritual", "Simian Spirit Guide",
+		},
+		Tutors: []string{
+			"Demonic Tutor", "Vampiric Tutor", "Imperial Seal", "Mystical Tutor",
+			"Enlightened Tutor", "Worldly Tutor", "Gamble", "Diabolic Intent",
+			"Grim Tutor", "Personal Tutor", "Green Sun's Zenith", "Finale of Devastation",
+		},
+		Combo: []string{
+			"Thassa's Oracle", "Demonic Consultation", "Tainted Pact",
+			"Underworld Breach", "Dramatic Reversal", "Isochron Scepter",
+			"Kiki-Jiki, Mirror Breaker", "Splinter Twin", "Dockside Extortionist",
+			"Food Chain", "Heliod, Sun-Crowned", "Walking Ballista",
+		},
+	}
+}
+
+// PowerFactor is one heuristic's contribution to a power estimate.
+type PowerFactor struct {
+	Factor       string  `json:"factor"`
+	Detail       string  `json:"detail"`
+	Contribution float64 `json:"contribution"`
+}
+
+// PowerEstimate is a heuristic Commander bracket estimate, from 1 (casual)
+// to 5 (competitive), with the factors that produced it.
+type PowerEstimate struct {
+	Bracket int           `json:"bracket"`
+	Score   float64       `json:"score"`
+	Factors []PowerFactor `json:"factors"`
+	// UnknownManaValue counts the nonland cards left out of the average
+	// mana value because their mana value isn't known.
+	UnknownManaValue int    `json:"unknownManaValue"`
+	Note             string `json:"note"`
+}
+
+// manaValue returns the card's mana value and whether it is known. A zero
+// cmc is indistinguishable from a missing one, so it is only trusted when
+// the card database has the card.
+func manaValue(game string, card DeckCard) (float64, bool) {
+	if card.CMC != 0 {
+		return card.CMC, true
+	}
+	if cardDB == nil {
+		return 0, false
+	}
+	if card.ID != "" {
+		if known, ok := cardDB.Lookup(game, card.ID); ok {
+			return known.CMC, true
+		}
+	}
+	if card.Name != "" {
+		if known, ok := cardDB.LookupName(game, card.Name); ok {
+			return known.CMC, true
+		}
+	}
+	return 0, false
+}
+
+// estimatePower scores a deck starting from bracket 1 and adding the
+// contribution of each factor: fast mana, tutors and combo pieces from
+// rules.PowerCards, a low average mana value, and a lean land count. Cards
+// whose mana value isn't known are left out of the average and counted in
+// UnknownManaValue. The bracket is the rounded score, clamped to 1-5.
+func estimatePower(deck *Deck) PowerEstimate {
+	cards := append(commandersOf(deck), deck.Cards...)
+
+	count := func(names []string) (int, string) {
+		want := map[string]bool{}
+		for _, name := range names {
+			want[normalizeName(deck.Game, name)] = true
+		}
+		n := 0
+		var found []string
+		for _, card := range cards {
+			if want[cardKey(deck.Game, card)] {
+				n += card.Count
+				found = append(found, displayName(card))
+			}
+		}
+		if len(found) == 0 {
+			return n, ""
+		}
+		return n, " (" + strings.Join(found, ", ") + ")"
+	}
+	capped := func(v, max float64) float64 { return math.Min(v, max) }
+
+	est := PowerEstimate{Score: 1, Note: "This is a rough heuristic, not a rules check."}
+	add := func(factor, detail string, contribution float64) {
+		est.Factors = append(est.Factors, PowerFactor{Factor: factor, Detail: detail, Contribution: contribution})
+		est.Score += contribution
+	}
+
+	n, found := count(rules.PowerCards.FastMana)
+	add("fastMana", fmt.Sprintf("fast mana: %d%s", n, found), capped(0.5*float64(n), 1.5))
+	n, found = count(rules.PowerCards.Tutors)
+	add("tutors", fmt.Sprintf("tutors: %d%s", n, found), capped(0.25*float64(n), 1))
+	n, found = count(rules.PowerCards.Combo)
+	add("combo", fmt.Sprintf("combo pieces: %d%s", n, found), capped(0.5*float64(n), 1.5))
+
+	spells, mv := 0, 0.0
+	for _, card := range cards {
+		if land, _ := isLand(card); land {
+			continue
+		}
+		if v, ok := manaValue(deck.Game, card); ok {
+			spells += card.Count
+			mv += v * float64(card.Count)
+		} else {
+			est.UnknownManaValue += card.Count
+		}
+	}
+	unknown := ""
+	if est.UnknownManaValue > 0 {
+		unknown = fmt.Sprintf(" (%d cards without a mana value left out)", est.UnknownManaValue)
+	}
+	if spells > 0 {
+		avg := mv / float64(spells)
+		contribution := 0.0
+		switch {
+		case avg < 2.5:
+			contribution = 0.5
+		case avg > 3.5:
+			contribution = -0.25
+		}
+		add("averageManaValue", fmt.Sprintf("average mana value: %.2f%s", avg, unknown), contribution)
+	} else if est.UnknownManaValue > 0 {
+		add("averageManaValue", "average mana value: unknown"+unknown, 0)
+	}
+
+	lands, _ := countLands(deck)
+	contribution := 0.0
+	if lands > 0 && lands < 33 {
+		contribution = 0.25
+	}
+	add("landCount", fmt.Sprintf("lands: %d", lands), contribution)
+
+	est.Bracket = int(math.Round(est.Score))
+	if est.Bracket < 1 {
+		est.Bracket = 1
+	}
+	if est.Bracket > 5 {
+		est.Bracket = 5
+	}
+	return est
+}
+
+func deckPowerHandler(w http.ResponseWriter, r *http.Request) {
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(estimatePower(deck))
+}
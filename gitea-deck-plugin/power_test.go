@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testCardDB loads cards into a card database from a temporary file.
+func testCardDB(t *testing.T, cards string) *memoryCardDB {
+	path := filepath.Join(t.TempDir(), "cards.json")
+	if err := os.WriteFile(path, []byte(cards), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := loadCardDB([]string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestEstimatePowerManaValue(t *testing.T) {
+	saved := cardDB
+	defer func() { cardDB = saved }()
+	cardDB = testCardDB(t, `[
+		{"id": "orn", "name": "Ornithopter", "game": "mtg", "type": "Artifact Creature", "cmc": 0},
+		{"id": "opt", "name": "Opt", "game": "mtg", "type": "Instant", "cmc": 1}
+	]`)
+
+	tests := []struct {
+		name    string
+		cards   []DeckCard
+		detail  string
+		unknown int
+	}{
+		{"all known", []DeckCard{{Name: "Wrath of God", CMC: 4, Count: 2}, {Name: "Divination", CMC: 3, Count: 2}},
+			"average mana value: 3.50", 0},
+		{"unknown left out", []DeckCard{{Name: "Wrath of God", CMC: 4, Count: 2}, {Name: "Mystery Spell", Type: "Sorcery", Count: 6}},
+			"average mana value: 4.00 (6 cards without a mana value left out)", 6},
+		{"zero from the card database", []DeckCard{{Name: "Wrath of God", CMC: 4, Count: 1}, {Name: "Ornithopter", Count: 1}},
+			"average mana value: 2.00", 0},
+		{"looked up by ID", []DeckCard{{ID: "opt", Count: 2}, {Name: "Wrath of God", CMC: 4, Count: 1}},
+			"average mana value: 2.00", 0},
+		{"lands aren't counted", []DeckCard{{Name: "Opt", Count: 4}, {Name: "Island", Count: 20}},
+			"average mana value: 1.00", 0},
+		{"none known", []DeckCard{{Name: "Mystery Spell", Count: 3}},
+			"average mana value: unknown (3 cards without a mana value left out)", 3},
+	}
+	for _, tt := range tests {
+		est := estimatePower(&Deck{Game: "mtg", Format: "commander", Cards: tt.cards})
+		detail := ""
+		for _, f := range est.Factors {
+			if f.Factor == "averageManaValue" {
+				detail = f.Detail
+			}
+		}
+		if detail != tt.detail || est.UnknownManaValue != tt.unknown {
+			t.Errorf("%s: %q with %d unknown, want %q with %d", tt.name, detail, est.UnknownManaValue, tt.detail, tt.unknown)
+		}
+	}
+}
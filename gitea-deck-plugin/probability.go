@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// logChoose returns ln(n choose k).
+func logChoose(n, k int) float64 {
+	if k < 0 || k > n {
+		return math.Inf(-1)
+	}
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
+
+// hypergeometric returns the probability of drawing exactly k successes in
+// draws cards from a population containing successes successes.
+func hypergeometric(population, successes, draws, k int) float64 {
+	if draws > population {
+		return 0
+	}
+	return math.Exp(logChoose(successes, k) + logChoose(population-successes, draws-k) - logChoose(population, draws))
+}
+
+// isLand reports whether a card is a land by its type, falling back to the
+// basic land names when the card has no type data. known is false when the
+// fallback was used for a non-basic.
+func isLand(card DeckCard) (land, known bool) {
+	if card.Land || hasType(card, "land") {
+		return true, true
+	}
+	if card.Type != "" {
+		return false, true
+	}
+	if isBasicLand(card.Name) {
+		return true, true
+	}
+	return false, false
+}
+
+// countLands returns the number of lands in the main deck and the number of
+// untyped cards that were assumed to be nonland.
+func countLands(deck *Deck) (lands, guessed int) {
+	for _, card := range deck.Cards {
+		land, known := isLand(card)
+		if land {
+			lands += card.Count
+		}
+		if !known {
+			guessed += card.Count
+		}
+	}
+	return lands, guessed
+}
+
+// landProbability returns the probability of each land count from 0 to hand
+// in an opening hand of the given size.
+func landProbability(deck *Deck, hand int) []float64 {
+	lands, _ := countLands(deck)
+	return landDistribution(deckSize(deck), lands, hand)
+}
+
+func landDistribution(size, lands, hand int) []float64 {
+	dist := make([]float64, hand+1)
+	for k := 0; k <= hand; k++ {
+		dist[k] = hypergeometric(size, lands, hand, k)
+	}
+	return dist
+}
+
+// deckSize is the number of cards in the main deck.
+func deckSize(deck *Deck) int {
+	total := 0
+	for _, card := range deck.Cards {
+		total += card.Count
+	}
+	return total
+}
+
+type landProbabilityResponse struct {
+	DeckSize     int       `json:"deckSize"`
+	Lands        int       `json:"lands"`
+	Hand         int       `json:"hand"`
+	Distribution []float64 `json:"distribution"`
+	// Keepable is the chance of 2 to hand-2 lands; KeepableMulligan also
+	// allows one mulligan to a fresh hand.
+	Keepable         float64  `json:"keepable"`
+	KeepableMulligan float64  `json:"keepableWithMulligan"`
+	Warnings         []string `json:"warnings"`
+}
+
+func landProbabilityHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	hand := 7
+	if v := q.Get("hand"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 20 {
+			http.Error(w, "hand must be between 1 and 20", http.StatusBadRequest)
+			return
+		}
+		hand = n
+	}
+
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	size := deckSize(deck)
+	if hand > size {
+		http.Error(w, fmt.Sprintf("hand size %d is larger than the deck (%d cards)", hand, size), http.StatusBadRequest)
+		return
+	}
+
+	resp := landProbabilityResponse{DeckSize: size, Hand: hand, Warnings: []string{}}
+	if v := q.Get("lands"); v != "" && v != "auto" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > size {
+			http.Error(w, "lands must be auto or a count no larger than the deck", http.StatusBadRequest)
+			return
+		}
+		resp.Lands = n
+		resp.Distribution = landDistribution(size, n, hand)
+	} else {
+		lands, guessed := countLands(deck)
+		resp.Lands = lands
+		resp.Distribution = landProbability(deck, hand)
+		if guessed > 0 {
+			resp.Warnings = append(resp.Warnings, fmt.Sprintf("%d cards without type data were assumed to be nonland", guessed))
+		}
+	}
+
+	for k := 2; k <= hand-2; k++ {
+		resp.Keepable += resp.Distribution[k]
+	}
+	resp.KeepableMulligan = 1 - (1-resp.Keepable)*(1-resp.Keepable)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// copiesOf counts the main deck copies of a card, matching by normalized
+// name.
+func copiesOf(deck *Deck, name string) int {
+	key := normalizeName(deck.Game, name)
+	n := 0
+	for _, card := range deck.Cards {
+		if cardKey(deck.Game, card) == key {
+			n += card.Count
+		}
+	}
+	return n
+}
+
+// comboProbability returns the chance of having seen at least one copy of
+// each of two cards by the given turn, drawing a 7-card opening hand and one
+// card per turn. It is exact: by inclusion-exclusion, one minus the chances
+// of missing either card plus the chance of missing both. When cardA and
+// cardB are the same card it is the chance of having seen two copies.
+func comboProbability(deck *Deck, cardA, cardB string, turn int) float64 {
+	size := deckSize(deck)
+	seen := size
+	if turn < size-7 {
+		seen = 7 + turn
+	}
+	a, b := copiesOf(deck, cardA), copiesOf(deck, cardB)
+	miss := func(copies int) float64 {
+		return math.Exp(logChoose(size-copies, seen) - logChoose(size, seen))
+	}
+	if normalizeName(deck.Game, cardA) == normalizeName(deck.Game, cardB) {
+		return 1 - hypergeometric(size, a, seen, 0) - hypergeometric(size, a, seen, 1)
+	}
+	return 1 - miss(a) - miss(b) + miss(a+b)
+}
+
+type comboRequest struct {
+	Deck  Deck   `json:"deck"`
+	CardA string `json:"cardA"`
+	CardB string `json:"cardB"`
+	Turn  int    `json:"turn"`
+}
+
+type comboResponse struct {
+	CardA       string  `json:"cardA"`
+	CardB       string  `json:"cardB"`
+	Turn        int     `json:"turn"`
+	Probability float64 `json:"probability"`
+}
+
+func comboProbabilityHandler(w http.ResponseWriter, r *http.Request) {
+	var req comboRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	size := deckSize(&req.Deck)
+	if size < 7 {
+		http.Error(w, "deck has fewer than 7 cards", http.StatusBadRequest)
+		return
+	}
+	if req.Turn < 0 || req.Turn > size {
+		http.Error(w, fmt.Sprintf("turn must be between 0 and the deck size, %d", size), http.StatusBadRequest)
+		return
+	}
+	if normalizeName(req.Deck.Game, req.CardA) == normalizeName(req.Deck.Game, req.CardB) {
+		if copiesOf(&req.Deck, req.CardA) < 2 {
+			http.Error(w, fmt.Sprintf("%q needs at least 2 copies in the main deck to be both cards", req.CardA), http.StatusBadRequest)
+			return
+		}
+	}
+	for _, name := range []string{req.CardA, req.CardB} {
+		if copiesOf(&req.Deck, name) == 0 {
+			http.Error(w, fmt.Sprintf("%q is not in the main deck", name), http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comboResponse{
+		CardA:       req.CardA,
+		CardB:       req.CardB,
+		Turn:        req.Turn,
+		Probability: comboProbability(&req.Deck, req.CardA, req.CardB, req.Turn),
+	})
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func comboDeck() *Deck {
+	return &Deck{Game: "mtg", Cards: []DeckCard{
+		{Name: "Thassa's Oracle", Count: 4},
+		{Name: "Tainted Pact", Count: 4},
+		{Name: "Island", Count: 52},
+	}}
+}
+
+func TestComboProbability(t *testing.T) {
+	tests := []struct {
+		name         string
+		cardA, cardB string
+		turn         int
+		want         float64
+	}{
+		// 1 - 2*C(56,7)/C(60,7) + C(52,7)/C(60,7)
+		{"opening hand", "Thassa's Oracle", "Tainted Pact", 0, 0.1454},
+		{"whole deck", "Thassa's Oracle", "Tainted Pact", 53, 1},
+		{"past the deck", "Thassa's Oracle", "Tainted Pact", 1 << 62, 1},
+		// 1 - P(0 of 4) - P(1 of 4) in 7 of 60
+		{"same card", "Thassa's Oracle", "thassa's oracle", 0, 0.0632},
+		{"same card, whole deck", "Island", "Island", 53, 1},
+	}
+	for _, tt := range tests {
+		got := comboProbability(comboDeck(), tt.cardA, tt.cardB, tt.turn)
+		if math.IsNaN(got) || math.Abs(got-tt.want) > 0.0005 {
+			t.Errorf("%s: got %.4f, want %.4f", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestComboProbabilityHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		cardA, cardB string
+		turn         int
+		want         int
+	}{
+		{"valid", "Thassa's Oracle", "Tainted Pact", 3, http.StatusOK},
+		{"negative turn", "Thassa's Oracle", "Tainted Pact", -1, http.StatusBadRequest},
+		{"turn past the deck size", "Thassa's Oracle", "Tainted Pact", 61, http.StatusBadRequest},
+		{"huge turn", "Thassa's Oracle", "Tainted Pact", math.MaxInt, http.StatusBadRequest},
+		{"same card twice", "Island", "island", 3, http.StatusOK},
+		{"missing card", "Thassa's Oracle", "Demonic Consultation", 3, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		body, _ := json.Marshal(comboRequest{Deck: *comboDeck(), CardA: tt.cardA, CardB: tt.cardB, Turn: tt.turn})
+		rec := httptest.NewRecorder()
+		comboProbabilityHandler(rec, httptest.NewRequest(http.MethodPost, "/combo-probability", strings.NewReader(string(body))))
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, rec.Code, tt.want, strings.TrimSpace(rec.Body.String()))
+			continue
+		}
+		if rec.Code == http.StatusOK {
+			var resp comboResponse
+			json.Unmarshal(rec.Body.Bytes(), &resp)
+			if resp.Probability < 0 || resp.Probability > 1 {
+				t.Errorf("%s: probability %v out of range", tt.name, resp.Probability)
+			}
+		}
+	}
+}
+
+func TestComboProbabilityNeedsTwoCopies(t *testing.T) {
+	deck := &Deck{Game: "mtg", Cards: []DeckCard{{Name: "Thassa's Oracle", Count: 1}, {Name: "Island", Count: 59}}}
+	body, _ := json.Marshal(comboRequest{Deck: *deck, CardA: "Thassa's Oracle", CardB: "Thassa's Oracle", Turn: 3})
+	rec := httptest.NewRecorder()
+	comboProbabilityHandler(rec, httptest.NewRequest(http.MethodPost, "/combo-probability", strings.NewReader(string(body))))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("one copy named twice: status %d, want 400", rec.Code)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// problemJSON is the RFC 9457 Problem Details media type.
+const problemJSON = "application/problem+json"
+
+// problemTypeInvalidDeck identifies the problem returned for decks that
+// fail validation.
+const problemTypeInvalidDeck = "urn:gitea-deck-plugin:problem:invalid-deck"
+
+// ProblemDetails is an RFC 9457 Problem Details object for a failed
+// validation. Errors is an extension member carrying the error issues.
+type ProblemDetails struct {
+	Type   string  `json:"type"`
+	Title  string  `json:"title"`
+	Status int     `json:"status"`
+	Detail string  `json:"detail"`
+	Errors []Issue `json:"errors"`
+}
+
+// toProblemDetails describes an invalid validation result as a 422
+// problem. The detail gives the first error, and errors lists all of them,
+// including warnings that warnings-as-errors escalated.
+func toProblemDetails(result ValidationResult) ProblemDetails {
+	problem := ProblemDetails{
+		Type:   problemTypeInvalidDeck,
+		Title:  "Deck failed validation",
+		Status: http.StatusUnprocessableEntity,
+		Errors: []Issue{},
+	}
+	for _, issue := range result.Issues {
+		if issue.Severity == SeverityError {
+			problem.Errors = append(problem.Errors, issue)
+		}
+	}
+	switch len(problem.Errors) {
+	case 0:
+		problem.Detail = "The deck is not valid."
+	case 1:
+		problem.Detail = problem.Errors[0].Message
+	default:
+		problem.Detail = fmt.Sprintf("%s (and %d more)", problem.Errors[0].Message, len(problem.Errors)-1)
+	}
+	return problem
+}
+
+// wantsProblem reports whether the request asked for failures as Problem
+// Details, with format=problem or an Accept header listing the problem
+// media type.
+func wantsProblem(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "problem" {
+		return true
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept)); err == nil && mediaType == problemJSON {
+			return true
+		}
+	}
+	return false
+}
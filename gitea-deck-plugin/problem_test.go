@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestProblemDetailsResponse(t *testing.T) {
+	tests := []struct {
+		name   string
+		deck   string
+		query  string
+		status int
+		errors int
+		detail string // prefix of the expected detail
+	}{
+		{"warnings only", `{"game":"mtg","format":"modern","cards":[{"name":"Island","count":60}]}`, "", http.StatusOK, 0, ""},
+		{"warnings as errors", `{"game":"mtg","format":"modern","cards":[{"name":"Island","count":60}]}`, "&warnings-as-errors=true", http.StatusUnprocessableEntity, 2, "Deck has no name (and 1 more)"},
+		{"one error", `{"game":"mtg","format":"modern","name":"Islands","cards":[{"name":"Island","count":20}]}`, "", http.StatusUnprocessableEntity, 1, "Modern"},
+	}
+	for _, tt := range tests {
+		target := "/validate?format=problem&content=" + url.QueryEscape(tt.deck) + tt.query
+		rec := httptest.NewRecorder()
+		validateDeckHandler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.status)
+			continue
+		}
+		if tt.status == http.StatusOK {
+			continue
+		}
+		var problem ProblemDetails
+		if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(problem.Errors) != tt.errors || !strings.HasPrefix(problem.Detail, tt.detail) {
+			t.Errorf("%s: %d errors with detail %q, want %d starting %q", tt.name, len(problem.Errors), problem.Detail, tt.errors, tt.detail)
+		}
+		for _, issue := range problem.Errors {
+			if issue.Severity != SeverityError {
+				t.Errorf("%s: problem lists %s with severity %s", tt.name, issue.Code, issue.Severity)
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// errProviderBusy is returned when no external provider slot frees up in
+// time. Handlers report it as 503.
+var errProviderBusy = errors.New("external providers are busy, try again later")
+
+// providerLimiter bounds the calls to external providers, such as the card
+// image host, in flight across all endpoints, so a burst of requests can't
+// stampede a third party.
+type providerLimiter struct {
+	slots chan struct{}
+	// wait is how long a call may wait for a slot before failing with
+	// errProviderBusy.
+	wait time.Duration
+}
+
+func newProviderLimiter(concurrency int, wait time.Duration) *providerLimiter {
+	return &providerLimiter{slots: make(chan struct{}, concurrency), wait: wait}
+}
+
+// providerLimit is shared by every external provider call. It is set once
+// at startup from -provider-concurrency and -provider-wait.
+var providerLimit = newProviderLimiter(8, 5*time.Second)
+
+// acquire takes a slot, waiting at most l.wait or until ctx is done. The
+// returned release must be called once the call finishes.
+func (l *providerLimiter) acquire(ctx context.Context) (release func(), err error) {
+	timer := time.NewTimer(l.wait)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return func() { <-l.slots }, nil
+	case <-timer.C:
+		return nil, errProviderBusy
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// retryAfter formats d as a Retry-After value in whole seconds, at least 1.
+func retryAfter(d time.Duration) string {
+	secs := int((d + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return strconv.Itoa(secs)
+}
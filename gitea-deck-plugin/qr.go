@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	defaultQRSize = 256
+	maxQRSize     = 1024
+)
+
+// viewerDeck returns a copy of deck for a viewer link, with the IDs of cards
+// that have a name dropped: the viewer shows names, and random IDs are most
+// of what doesn't compress in a share code.
+func viewerDeck(deck *Deck) *Deck {
+	out := *deck
+	strip := func(cards []DeckCard) []DeckCard {
+		if cards == nil {
+			return nil
+		}
+		stripped := make([]DeckCard, len(cards))
+		for i, card := range cards {
+			if card.Name != "" {
+				card.ID = ""
+			}
+			stripped[i] = card
+		}
+		return stripped
+	}
+	one := func(card *DeckCard) *DeckCard {
+		if card == nil {
+			return nil
+		}
+		return &strip([]DeckCard{*card})[0]
+	}
+	out.Cards = strip(deck.Cards)
+	out.Sideboard = strip(deck.Sideboard)
+	out.Maybeboard = strip(deck.Maybeboard)
+	out.Commanders = strip(deck.Commanders)
+	out.Battlefields = strip(deck.Battlefields)
+	out.Runes = strip(deck.Runes)
+	out.Commander = one(deck.Commander)
+	out.Companion = one(deck.Companion)
+	out.Oathbreaker = one(deck.Oathbreaker)
+	out.SignatureSpell = one(deck.SignatureSpell)
+	out.Legend = one(deck.Legend)
+	out.Battlefield = one(deck.Battlefield)
+	return &out
+}
+
+// shareURL returns a viewer link that embeds the deck as a share code, using
+// the forwarded scheme and host when the plugin runs behind a reverse proxy.
+// The compressed code of viewerDeck keeps full-size decks within what a QR
+// code holds.
+func shareURL(r *http.Request, deck *Deck) (string, error) {
+	code, err := encodeDeck(viewerDeck(deck))
+	if err != nil {
+		return "", err
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	host := r.Host
+	if fwd := r.Header.Get("X-Forwarded-Host"); fwd != "" {
+		host = fwd
+	}
+	return fmt.Sprintf("%s://%s/viewer/?code=%s", scheme, host, code), nil
+}
+
+func deckQRHandler(w http.ResponseWriter, r *http.Request) {
+	size := defaultQRSize
+	if v := r.URL.Query().Get("size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxQRSize {
+			http.Error(w, fmt.Sprintf("size must be between 1 and %d", maxQRSize), http.StatusBadRequest)
+			return
+		}
+		size = n
+	}
+
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	link, err := shareURL(r, deck)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("building share URL: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Medium error correction survives some damage to a printed code; Low
+	// holds about a quarter more data, enough for most decks that don't fit.
+	png, err := qrcode.Encode(link, qrcode.Medium, size)
+	if err != nil {
+		png, err = qrcode.Encode(link, qrcode.Low, size)
+	}
+	if err != nil {
+		http.Error(w, "deck is too large to fit in a QR code", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(png)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// commanderNonbasics is the nonbasic part of a typical 100-card Commander
+// deck.
+var commanderNonbasics = []string{
+	"Sol Ring", "Arcane Signet", "Command Tower", "Swords to Plowshares", "Path to Exile",
+	"Counterspell", "Swan Song", "Cyclonic Rift", "Rhystic Study", "Mystic Remora",
+	"Smothering Tithe", "Esper Sentinel", "Demonic Tutor", "Vampiric Tutor", "Enlightened Tutor",
+	"Mystical Tutor", "Toxic Deluge", "Damnation", "Wrath of God", "Teferi's Protection",
+	"Heroic Intervention", "Beast Within", "Nature's Claim", "Chaos Warp", "Generous Gift",
+	"Anguished Unmaking", "Assassin's Trophy", "Kaya's Guile", "Farseek", "Nature's Lore",
+	"Three Visits", "Cultivate", "Kodama's Reach", "Skyshroud Claim", "Birds of Paradise",
+	"Noble Hierarch", "Llanowar Elves", "Elvish Mystic", "Fyndhorn Elves", "Avacyn's Pilgrim",
+	"Doubling Season", "Deepglow Skate", "Oath of Teferi", "The Chain Veil", "Teferi, Hero of Dominaria",
+	"Vraska, Golgari Queen", "Nissa, Who Shakes the World", "Jace, the Mind Sculptor", "Karn Liberated", "Ugin, the Spirit Dragon",
+	"Tamiyo, Field Researcher", "Narset, Parter of Veils", "Flooded Strand", "Polluted Delta", "Windswept Heath",
+	"Misty Rainforest", "Verdant Catacombs", "Marsh Flats", "Hallowed Fountain", "Watery Grave",
+	"Overgrown Tomb", "Temple Garden", "Godless Shrine", "Breeding Pool",
+}
+
+// commanderDeck returns a 100-card Commander deck. With withIDs every
+// card carries a Scryfall-style UUID, which compresses poorly.
+func commanderDeck(withIDs bool) *Deck {
+	id := func(name string) string {
+		if !withIDs {
+			return ""
+		}
+		h := sha1.Sum([]byte(name))
+		return fmt.Sprintf("%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+	}
+	deck := &Deck{
+		Game:      "mtg",
+		Format:    "commander",
+		Name:      "Atraxa Superfriends",
+		Commander: &DeckCard{Name: "Atraxa, Praetors' Voice", ID: id("Atraxa, Praetors' Voice"), Count: 1, Set: "c16"},
+		Metadata:  DeckMetadata{Author: "gitea-user", Description: "Proliferate planeswalkers until someone concedes."},
+	}
+	for _, name := range commanderNonbasics {
+		deck.Cards = append(deck.Cards, DeckCard{Name: name, ID: id(name), Count: 1, Set: "cmm"})
+	}
+	for _, basic := range []string{"Plains", "Island", "Swamp", "Forest"} {
+		deck.Cards = append(deck.Cards, DeckCard{Name: basic, ID: id(basic), Count: 35 / 4, Set: "cmm"})
+	}
+	deck.Cards[len(deck.Cards)-1].Count += 99 - len(commanderNonbasics) - 4*(35/4)
+	return deck
+}
+
+func TestShareURLRoundTrip(t *testing.T) {
+	deck := commanderDeck(true)
+	r := httptest.NewRequest(http.MethodGet, "/api/deck/qr", nil)
+	r.Host = "decks.example.org"
+	link, err := shareURL(r, deck)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("share URL %q doesn't parse: %v", link, err)
+	}
+	if u.Host != "decks.example.org" || u.Path != "/viewer/" {
+		t.Errorf("share URL %q: want the viewer on the request host", link)
+	}
+	decoded, err := decodeDeck(u.Query().Get("code"))
+	if err != nil {
+		t.Fatalf("decoding the share URL's code: %v", err)
+	}
+	if decoded.Name != deck.Name || len(decoded.Cards) != len(deck.Cards) || decoded.Cards[0].Name != deck.Cards[0].Name {
+		t.Errorf("decoded deck %q with %d entries, want %q with %d", decoded.Name, len(decoded.Cards), deck.Name, len(deck.Cards))
+	}
+}
+
+func TestDeckQRFitsCommanderDeck(t *testing.T) {
+	tests := []struct {
+		name string
+		deck *Deck
+	}{
+		{"names only", commanderDeck(false)},
+		{"with IDs", commanderDeck(true)},
+	}
+	for _, tt := range tests {
+		if n := deckSize(tt.deck) + len(commandersOf(tt.deck)); n != 100 {
+			t.Fatalf("%s: test deck has %d cards, want 100", tt.name, n)
+		}
+		content, err := json.Marshal(tt.deck)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/api/deck/qr?"+url.Values{"content": {string(content)}}.Encode(), nil)
+		rec := httptest.NewRecorder()
+		deckQRHandler(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status %d, want 200 (%s)", tt.name, rec.Code, strings.TrimSpace(rec.Body.String()))
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "image/png" {
+			t.Errorf("%s: Content-Type %q, want image/png", tt.name, ct)
+		}
+	}
+}
+
+func TestViewerDeck(t *testing.T) {
+	tests := []struct {
+		card   DeckCard
+		wantID string
+	}{
+		{DeckCard{Name: "Sol Ring", ID: "abc", Count: 1}, ""},
+		{DeckCard{ID: "abc", Count: 1}, "abc"},
+		{DeckCard{Name: "Sol Ring", Count: 1}, ""},
+	}
+	for _, tt := range tests {
+		card := tt.card
+		deck := &Deck{Game: "mtg", Cards: []DeckCard{card}, Sideboard: []DeckCard{card}, Commander: &card}
+		out := viewerDeck(deck)
+		for zone, got := range map[string]DeckCard{"cards": out.Cards[0], "sideboard": out.Sideboard[0], "commander": *out.Commander} {
+			if got.ID != tt.wantID || got.Name != card.Name || got.Count != card.Count {
+				t.Errorf("%s %+v: got %+v, want ID %q", zone, card, got, tt.wantID)
+			}
+		}
+		if deck.Cards[0].ID != card.ID || deck.Commander.ID != card.ID {
+			t.Errorf("%+v: viewerDeck changed the original deck", card)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// routeError is the JSON body returned for requests that match no route.
+type routeError struct {
+	Error   string   `json:"error"`
+	Path    string   `json:"path"`
+	Allowed []string `json:"allowed,omitempty"`
+}
+
+func writeRouteError(w http.ResponseWriter, status int, body routeError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeRouteError(w, http.StatusNotFound, routeError{Error: "not found", Path: r.URL.Path})
+}
+
+// routeMethods are the methods probed when listing what a path allows.
+var routeMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// methodNotAllowedHandler reports the methods routes registers for the
+// request path, both in the body and in the Allow header.
+func methodNotAllowedHandler(routes chi.Routes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var allowed []string
+		for _, method := range routeMethods {
+			if routes.Match(chi.NewRouteContext(), method, r.URL.Path) {
+				allowed = append(allowed, method)
+			}
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeRouteError(w, http.StatusMethodNotAllowed, routeError{Error: "method not allowed", Path: r.URL.Path, Allowed: allowed})
+	}
+}
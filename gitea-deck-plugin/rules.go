@@ -0,0 +1,496 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rules holds the configurable data the validator consults. The defaults are
+// compiled in; a JSON file passed via -rules is layered on top of them.
+type Rules struct {
+	// NameNormalization is keyed by game. The "*" entry applies to games
+	// without an entry of their own.
+	NameNormalization map[string]NameNormalization `json:"nameNormalization"`
+
+	// LegalSets lists the set codes legal in each set-restricted format,
+	// keyed by format. Formats without an entry skip the set check.
+	LegalSets map[string][]string `json:"legalSets"`
+
+	// BannedCards lists the cards banned in each format, keyed by format.
+	// Formats without an entry have no banned list.
+	BannedCards map[string][]string `json:"bannedCards"`
+
+	// BannedCombos lists, per format, sets of cards that may not all be in
+	// the same deck, for house rules against combos whose pieces are
+	// fine on their own.
+	BannedCombos map[string][][]string `json:"bannedCombos,omitempty"`
+
+	// CopyLimitExceptions lists, per game, cards that override the format's
+	// copy limit with their own maximum. A maximum of 0 means unlimited.
+	CopyLimitExceptions map[string]map[string]int `json:"copyLimitExceptions"`
+
+	// CanlanderPoints maps card names to their Canadian Highlander point
+	// value. A deck's total points may not exceed CanlanderPointCap.
+	CanlanderPoints   map[string]int `json:"canlanderPoints"`
+	CanlanderPointCap int            `json:"canlanderPointCap"`
+
+	// FormatAliases maps alternative format names to the canonical name
+	// validation dispatches on.
+	FormatAliases map[string]string `json:"formatAliases"`
+
+	// PowerCards lists the cards the power estimate treats as fast mana,
+	// tutors and combo pieces.
+	PowerCards PowerCards `json:"powerCards"`
+
+	// CommanderBrackets defines the Commander brackets by level, and
+	// GameChangers is the list of cards the brackets limit.
+	CommanderBrackets map[int]CommanderBracket `json:"commanderBrackets"`
+	GameChangers      []string                 `json:"gameChangers"`
+
+	// DeckNameMaxLength is the longest deck name allowed, in characters,
+	// keyed by game. The "*" entry applies to games without an entry of
+	// their own; 0 means no limit.
+	DeckNameMaxLength map[string]int `json:"deckNameMaxLength"`
+
+	// Tokens maps card names to the tokens they create, for the
+	// tokens-needed endpoint.
+	Tokens map[string][]string `json:"tokens"`
+
+	// Formats defines additional MTG formats from configuration, keyed by
+	// format. They also use the LegalSets and BannedCards entries under
+	// the same key.
+	Formats map[string]FormatRules `json:"formats"`
+
+	// LandBands is the recommended land count range keyed by deck size.
+	// Sizes without an entry skip the land-count advisory.
+	LandBands map[int]LandBand `json:"landBands"`
+
+	// ResourceFloors is the recommended minimum resource count, as counted
+	// by resourceCount, keyed by game. Games without an entry skip the
+	// resource advisory.
+	ResourceFloors map[string]int `json:"resourceFloors,omitempty"`
+
+	// Changes are dated updates to LegalSets and BannedCards, applied by
+	// rulesAsOf. Each entry replaces the lists for the formats it names from
+	// its date until a later entry for the same format.
+	Changes []RulesChange `json:"changes"`
+
+	// AllowedTags is the tag vocabulary for Metadata.Tags. Tags compare
+	// case-insensitively; an empty list allows any tag.
+	AllowedTags []string `json:"allowedTags"`
+
+	// ThemeMinShare is the share of nonland cards, from 0 to 1, that should
+	// match a deck's "theme:" tag.
+	ThemeMinShare float64 `json:"themeMinShare"`
+
+	// nameReplacers holds the compiled Replacements of NameNormalization,
+	// keyed the same way. It is built by compileNameReplacers.
+	nameReplacers map[string]*strings.Replacer
+}
+
+// RulesChange is a dated legal set or banned list update. Date is
+// YYYY-MM-DD.
+type RulesChange struct {
+	Date        string              `json:"date"`
+	LegalSets   map[string][]string `json:"legalSets,omitempty"`
+	BannedCards map[string][]string `json:"bannedCards,omitempty"`
+}
+
+// rulesChangeDate is the layout of RulesChange.Date.
+const rulesChangeDate = "2006-01-02"
+
+// LandBand is an inclusive range of recommended land counts.
+type LandBand struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// rules is the active rule set. It is set once at startup and treated as
+// read-only afterwards.
+var rules = defaultRules()
+
+func defaultRules() *Rules {
+	r := &Rules{
+		NameNormalization: map[string]NameNormalization{
+			"*": defaultNameNormalization(),
+		},
+		LegalSets: map[string][]string{
+			"premodern": {
+				"4ED", "ICE", "CHR", "HML", "ALL", "MIR", "VIS", "5ED", "POR", "WTH",
+				"TMP", "STH", "EXO", "P02", "USG", "ULG", "6ED", "UDS", "PTK", "S99",
+				"MMQ", "NEM", "PCY", "INV", "PLS", "7ED", "APC", "ODY", "TOR", "JUD",
+				"ONS", "LGN", "SCG",
+			},
+			"oldschool": {"LEA", "LEB", "2ED", "ARN", "ATQ", "3ED", "LEG", "DRK", "FEM"},
+		},
+		BannedCards: map[string][]string{
+			"legacy": {
+				"Ancestral Recall", "Arcum's Astrolabe", "Balance", "Bazaar of Baghdad",
+				"Black Lotus", "Channel", "Chaos Orb", "Cleanse", "Crusade",
+				"Deathrite Shaman", "Demonic Consultation", "Demonic Tutor",
+				"Dig Through Time", "Dreadhorde Arcanist", "Earthcraft",
+				"Expressive Iteration", "Falling Star", "Fastbond", "Flash",
+				"Frantic Search", "Gitaxian Probe", "Goblin Recruiter", "Grief",
+				"Gush", "Hermit Druid", "Imperial Seal", "Imprison",
+				"Invoke Prejudice", "Jihad", "Library of Alexandria",
+				"Lurrus of the Dream-Den", "Mana Crypt", "Mana Drain", "Mana Vault",
+				"Memory Jar", "Mental Misstep", "Mind Twist", "Mind's Desire",
+				"Mishra's Workshop", "Mox Emerald", "Mox Jet", "Mox Pearl",
+				"Mox Ruby", "Mox Sapphire", "Mystical Tutor", "Necropotence",
+				"Oko, Thief of Crowns", "Pradesh Gypsies", "Ragavan, Nimble Pilferer",
+				"Sensei's Divining Top", "Shahrazad", "Skullclamp", "Sol Ring",
+				"Stone-Throwing Devils", "Strip Mine", "Survival of the Fittest",
+				"Time Vault", "Time Walk", "Timetwister", "Tinker",
+				"Tolarian Academy", "Treasure Cruise", "Underworld Breach",
+				"Vampiric Tutor", "Wheel of Fortune", "Windfall", "Wrenn and Six",
+				"Yawgmoth's Bargain", "Yawgmoth's Will", "Zirda, the Dawnwaker",
+			},
+			"premodern": {
+				"Amulet of Quoz", "Balance", "Brainstorm", "Bronze Tablet", "Channel",
+				"Demonic Consultation", "Earthcraft", "Entomb", "Flash",
+				"Force of Will", "Goblin Recruiter", "Grim Monolith", "Jeweled Bird",
+				"Mind Twist", "Mystical Tutor", "Necropotence", "Rebirth",
+				"Strip Mine", "Tempest Efreet", "Timmerian Fiends", "Tolarian Academy",
+				"Vampiric Tutor", "Windfall", "Worldgorger Dragon",
+				"Yawgmoth's Bargain", "Yawgmoth's Will",
+			},
+			"oldschool": {
+				"Bronze Tablet", "Contract from Below", "Darkpact", "Demonic Attorney",
+				"Jeweled Bird", "Rebirth", "Shahrazad", "Tempest Efreet", "Timmerian Fiends",
+			},
+		},
+		CopyLimitExceptions: map[string]map[string]int{
+			"mtg": {
+				"Relentless Rats":        0,
+				"Shadowborn Apostle":     0,
+				"Persistent Petitioners": 0,
+				"Rat Colony":             0,
+				"Dragon's Approach":      0,
+				"Slime Against Humanity": 0,
+				"Templar Knight":         0,
+				"Hare Apparent":          0,
+				"Seven Dwarves":          7,
+				"Nazgûl":                 9,
+			},
+		},
+		CanlanderPoints:   map[string]int{},
+		CanlanderPointCap: 10,
+		FormatAliases: map[string]string{
+			"edh":                 "commander",
+			"cmdr":                "commander",
+			"elder dragon":        "commander",
+			"std":                 "standard",
+			"mod":                 "modern",
+			"ch":                  "canlander",
+			"canadian highlander": "canlander",
+			"sealed":              "pool",
+			"draft":               "pool",
+		},
+		PowerCards:        defaultPowerCards(),
+		DeckNameMaxLength: map[string]int{"*": 100},
+		CommanderBrackets: defaultCommanderBrackets(),
+		GameChangers:      defaultGameChangers(),
+		Tokens:            defaultTokenMap(),
+		Formats:           defaultFormats(),
+		LandBands: map[int]LandBand{
+			40:  {Min: 16, Max: 18},
+			60:  {Min: 17, Max: 26},
+			100: {Min: 36, Max: 40},
+		},
+		ResourceFloors: map[string]int{"pokemon": 8},
+		ThemeMinShare:  0.25,
+	}
+	r.compileNameReplacers()
+	return r
+}
+
+// canonicalFormat resolves a format name through the alias map. Matching
+// ignores case and surrounding whitespace.
+func canonicalFormat(format string) string {
+	f := strings.ToLower(strings.TrimSpace(format))
+	if canonical, ok := rules.FormatAliases[f]; ok {
+		return canonical
+	}
+	return f
+}
+
+// formatDisplayName is the name of a format as used in messages.
+func formatDisplayName(format string) string {
+	if f, ok := rules.Formats[format]; ok && f.Name != "" {
+		return f.Name
+	}
+	return strings.Title(format)
+}
+
+// copyLimitException returns the copy limit override for a card, if any.
+// Names are compared after normalization.
+func copyLimitException(game, name string) (int, bool) {
+	key := normalizeName(game, name)
+	for card, max := range rules.CopyLimitExceptions[game] {
+		if normalizeName(game, card) == key {
+			return max, true
+		}
+	}
+	return 0, false
+}
+
+// loadRules reads a JSON rules file and merges it over the defaults. Map
+// entries in the file replace the default entry with the same key.
+func loadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading rules file: %w", err)
+	}
+
+	r := defaultRules()
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("parsing rules file: %w", err)
+	}
+	for _, change := range r.Changes {
+		if _, err := time.Parse(rulesChangeDate, change.Date); err != nil {
+			return nil, fmt.Errorf("parsing rules file: change date %q is not YYYY-MM-DD", change.Date)
+		}
+	}
+	r.compileNameReplacers()
+	return r, nil
+}
+
+// rulesAsOf returns the active rules with every change dated on or before
+// date applied to the legal set and banned lists.
+func rulesAsOf(date time.Time) *Rules {
+	if len(rules.Changes) == 0 {
+		return rules
+	}
+	changes := append([]RulesChange{}, rules.Changes...)
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Date < changes[j].Date })
+
+	out := *rules
+	out.LegalSets = map[string][]string{}
+	for format, sets := range rules.LegalSets {
+		out.LegalSets[format] = sets
+	}
+	out.BannedCards = map[string][]string{}
+	for format, cards := range rules.BannedCards {
+		out.BannedCards[format] = cards
+	}
+	for _, change := range changes {
+		// Dates were checked by loadRules.
+		if d, _ := time.Parse(rulesChangeDate, change.Date); d.After(date) {
+			break
+		}
+		for format, sets := range change.LegalSets {
+			out.LegalSets[format] = sets
+		}
+		for format, cards := range change.BannedCards {
+			out.BannedCards[format] = cards
+		}
+	}
+	return &out
+}
+
+// checkSetLegality errors for main deck cards from sets outside the format's
+// legal set list. Cards without set data can't be checked and produce a
+// single warning instead. Basic lands are legal from any printing.
+func checkSetLegality(deck *Deck, active *Rules, result *ValidationResult) {
+	sets, ok := active.LegalSets[deck.Format]
+	if !ok {
+		return
+	}
+	legal := map[string]bool{}
+	for _, set := range sets {
+		legal[strings.ToUpper(set)] = true
+	}
+
+	unknown := 0
+	for _, card := range deck.Cards {
+		if isBasicLand(card.Name) {
+			continue
+		}
+		if card.Set == "" {
+			unknown += card.Count
+			continue
+		}
+		if !legal[strings.ToUpper(card.Set)] {
+			result.addError(CodeSetNotLegal, displayName(card), displayName(card), card.Set, formatDisplayName(deck.Format))
+		}
+	}
+	if unknown > 0 {
+		result.addWarning(CodeSetUnknown, "", unknown)
+	}
+}
+
+// checkBannedCards errors once for each card in the deck that is on the
+// format's banned list, whichever zone it is in. A multi-face card is banned
+// if any of its faces is. Cards found only in the
+// sideboard get their own code so the message can say where they are.
+func checkBannedCards(deck *Deck, active *Rules, result *ValidationResult) {
+	list, ok := active.BannedCards[deck.Format]
+	if !ok {
+		return
+	}
+	banned := map[string]bool{}
+	for _, name := range list {
+		for _, n := range allNames(DeckCard{Name: name}) {
+			banned[normalizeName(deck.Game, n)] = true
+		}
+	}
+
+	withoutSideboard := *deck
+	withoutSideboard.Sideboard = nil
+	reported := map[string]bool{}
+	check := func(cards []DeckCard, code string) {
+		for _, card := range cards {
+			key := faceKey(deck.Game, card)
+			if reported[key] || !anyBanned(deck.Game, card, banned) {
+				continue
+			}
+			reported[key] = true
+			result.addError(code, displayName(card), displayName(card), formatDisplayName(deck.Format))
+		}
+	}
+	check(playedCards(&withoutSideboard), CodeBannedCard)
+	check(deck.Sideboard, CodeSideboardBannedCard)
+}
+
+// anyBanned reports whether any of a card's names is in banned.
+func anyBanned(game string, card DeckCard, banned map[string]bool) bool {
+	for _, name := range allNames(card) {
+		if banned[normalizeName(game, name)] {
+			return true
+		}
+	}
+	return false
+}
+
+// namedCard fills in an ID-only card's name from the card database, if one
+// is loaded and knows the ID, so it can be matched against lists of names.
+func namedCard(game string, card DeckCard) DeckCard {
+	if card.Name != "" || card.ID == "" || cardDB == nil {
+		return card
+	}
+	if known, ok := cardDB.Lookup(game, card.ID); ok {
+		card.Name = known.Name
+	}
+	return card
+}
+
+// checkPlaygroupBans warns once for each card in the deck that is on the
+// request's playgroup banlist, in any zone. ID-only cards are matched by
+// their name in the card database. Cards the format already bans are
+// skipped, since they have an error of their own.
+func checkPlaygroupBans(deck *Deck, bans []string, result *ValidationResult) {
+	if len(bans) == 0 {
+		return
+	}
+	banned := map[string]bool{}
+	for _, name := range bans {
+		for _, n := range allNames(DeckCard{Name: name}) {
+			banned[normalizeName(deck.Game, n)] = true
+		}
+	}
+	reported := map[string]bool{}
+	for _, issue := range result.Issues {
+		if issue.Code == CodeBannedCard || issue.Code == CodeSideboardBannedCard {
+			reported[faceKey(deck.Game, DeckCard{Name: issue.Card})] = true
+		}
+	}
+	for _, card := range playedCards(deck) {
+		card = namedCard(deck.Game, card)
+		key := faceKey(deck.Game, card)
+		if reported[key] || !anyBanned(deck.Game, card, banned) {
+			continue
+		}
+		reported[key] = true
+		result.addWarning(CodePlaygroupBanned, displayName(card), displayName(card))
+	}
+}
+
+// checkBannedCombos errors once for each combination in combos whose
+// cards are all in the deck, in any zone. Names are matched by any face,
+// like banned cards.
+func checkBannedCombos(deck *Deck, combos [][]string, result *ValidationResult) {
+	if len(combos) == 0 {
+		return
+	}
+	present := map[string]bool{}
+	for _, card := range playedCards(deck) {
+		for _, name := range allNames(card) {
+			present[normalizeName(deck.Game, name)] = true
+		}
+	}
+	for _, combo := range combos {
+		if len(combo) == 0 {
+			continue
+		}
+		all := true
+		for _, name := range combo {
+			if !present[normalizeName(deck.Game, name)] {
+				all = false
+				break
+			}
+		}
+		if all {
+			result.addError(CodeBannedCombo, "", strings.Join(combo, " + "), formatDisplayName(deck.Format))
+		}
+	}
+}
+
+// checkDeckName warns about a missing deck name and errors for names over
+// the game's length limit or containing control characters, since names end
+// up in file names and URLs.
+func checkDeckName(game, name string, result *ValidationResult) {
+	if strings.TrimSpace(name) == "" {
+		result.addWarning(CodeMissingDeckName, "")
+		return
+	}
+	max, ok := rules.DeckNameMaxLength[game]
+	if !ok {
+		max = rules.DeckNameMaxLength["*"]
+	}
+	if n := utf8.RuneCountInString(name); max > 0 && n > max {
+		result.addError(CodeDeckNameTooLong, "", n, max)
+	}
+	if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		result.addError(CodeDeckNameInvalid, "")
+	}
+}
+
+type formatRulesResponse struct {
+	*Rules
+	AllowedGames []string `json:"allowedGames,omitempty"`
+}
+
+func formatRulesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(formatRulesResponse{Rules: rules, AllowedGames: allowedGames})
+}
+
+// checkCanlanderPoints errors when the deck's pointed cards total more than
+// the Canadian Highlander point cap, listing each pointed card.
+func checkCanlanderPoints(deck *Deck, result *ValidationResult) {
+	points := map[string]int{}
+	for name, value := range rules.CanlanderPoints {
+		points[normalizeName(deck.Game, name)] = value
+	}
+
+	total := 0
+	var pointed []string
+	for _, card := range deck.Cards {
+		value, ok := points[cardKey(deck.Game, card)]
+		if !ok {
+			continue
+		}
+		total += value * card.Count
+		pointed = append(pointed, fmt.Sprintf("%s (%d)", displayName(card), value))
+	}
+	if total > rules.CanlanderPointCap {
+		result.addError(CodePointsCapExceeded, "", total, rules.CanlanderPointCap, strings.Join(pointed, ", "))
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// shareCodeVersion is the leading byte of every share code. Bump it when the
+// payload layout changes; decodeDeck rejects versions it doesn't know.
+const shareCodeVersion byte = 1
+
+// encodeDeck returns a compact share code for the deck: a version byte
+// followed by the gzip-compressed deck JSON, base64url-encoded.
+func encodeDeck(deck *Deck) (string, error) {
+	data, err := json.Marshal(deck)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(shareCodeVersion)
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// decodeDeck reverses encodeDeck. The decompressed deck may be at most
+// maxBodyBytes long.
+func decodeDeck(code string) (*Deck, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(code)
+	if err != nil {
+		return nil, errors.New("invalid share code: not base64url")
+	}
+	if len(raw) == 0 {
+		return nil, errors.New("invalid share code: empty")
+	}
+	if raw[0] != shareCodeVersion {
+		return nil, fmt.Errorf("invalid share code: unknown version %d", raw[0])
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(raw[1:]))
+	if err != nil {
+		return nil, errors.New("invalid share code: corrupt payload")
+	}
+	// A few bytes of gzip can expand to gigabytes, so decompress no more
+	// than a request body may hold.
+	data, err := io.ReadAll(io.LimitReader(zr, maxBodyBytes+1))
+	if err != nil {
+		return nil, errors.New("invalid share code: corrupt payload")
+	}
+	if int64(len(data)) > maxBodyBytes {
+		return nil, fmt.Errorf("invalid share code: deck is larger than %d bytes", maxBodyBytes)
+	}
+
+	var deck Deck
+	if err := json.Unmarshal(data, &deck); err != nil {
+		return nil, fmt.Errorf("invalid share code: %v", err)
+	}
+	return &deck, nil
+}
+
+// shareDecoders maps the source of a share code to its decoder, so codes
+// from other deckbuilders can be imported by adding an entry. "deckbuilder"
+// is the plugin's own encodeDeck format.
+var shareDecoders = map[string]func(string) (*Deck, error){
+	"deckbuilder": decodeDeck,
+}
+
+// decodeShareCode decodes a share code, or a share URL carrying it in a
+// code query parameter, with the decoder for source. Without a source,
+// each decoder is tried in name order and the first that succeeds wins.
+func decodeShareCode(source, code string) (*Deck, error) {
+	code = strings.TrimSpace(code)
+	if u, err := url.Parse(code); err == nil && u.Scheme != "" {
+		if code = u.Query().Get("code"); code == "" {
+			return nil, errors.New("share URL has no code parameter")
+		}
+	}
+
+	if source != "" {
+		decode, ok := shareDecoders[source]
+		if !ok {
+			return nil, fmt.Errorf("unknown share code source %q", source)
+		}
+		return decode(code)
+	}
+
+	sources := make([]string, 0, len(shareDecoders))
+	for name := range shareDecoders {
+		sources = append(sources, name)
+	}
+	sort.Strings(sources)
+	var failures []string
+	for _, name := range sources {
+		deck, err := shareDecoders[name](code)
+		if err == nil {
+			return deck, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+	}
+	return nil, fmt.Errorf("unrecognized share code (%s)", strings.Join(failures, "; "))
+}
+
+type shareCodeResponse struct {
+	Code string `json:"code"`
+}
+
+func encodeDeckHandler(w http.ResponseWriter, r *http.Request) {
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	code, err := encodeDeck(deck)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("encoding deck: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(shareCodeResponse{Code: code})
+}
+
+func decodeDeckHandler(w http.ResponseWriter, r *http.Request) {
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		http.Error(w, "code parameter required", http.StatusBadRequest)
+		return
+	}
+
+	deck, err := decodeDeck(code)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deck)
+}
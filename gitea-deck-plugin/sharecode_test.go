@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestShareCodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		deck *Deck
+	}{
+		{"empty", &Deck{Game: "mtg"}},
+		{"named cards", &Deck{Game: "mtg", Format: "modern", Name: "Burn", Cards: []DeckCard{{Name: "Lightning Bolt", Count: 4}, {Name: "Mountain", Count: 20}}}},
+		{"IDs and zones", &Deck{Game: "mtg", Format: "commander",
+			Commander: &DeckCard{ID: "a1b2", Count: 1},
+			Cards:     []DeckCard{{ID: "c3d4", Name: "Sol Ring", Count: 1, Set: "cmm"}},
+			Sideboard: []DeckCard{{Name: "Lim-Dûl's Vault", Count: 1}}}},
+		{"full Commander deck", commanderDeck(true)},
+	}
+	for _, tt := range tests {
+		code, err := encodeDeck(tt.deck)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if strings.ContainsAny(code, "+/=") {
+			t.Errorf("%s: code %q isn't URL-safe", tt.name, code)
+		}
+		got, err := decodeDeck(code)
+		if err != nil {
+			t.Fatalf("%s: decoding: %v", tt.name, err)
+		}
+		if want, _ := encodeDeck(got); want != code {
+			t.Errorf("%s: decoded deck encodes differently", tt.name)
+		}
+	}
+}
+
+// rawShareCode builds a share code with the given version byte around
+// payload, gzip-compressed unless payload is nil.
+func rawShareCode(version byte, payload []byte) string {
+	var buf bytes.Buffer
+	buf.WriteByte(version)
+	if payload != nil {
+		zw := gzip.NewWriter(&buf)
+		zw.Write(payload)
+		zw.Close()
+	}
+	return base64.RawURLEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestDecodeDeckErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{"not base64", "not a code!", "not base64url"},
+		{"empty", "", "empty"},
+		{"unknown version", rawShareCode(9, []byte(`{}`)), "unknown version 9"},
+		{"not gzip", base64.RawURLEncoding.EncodeToString([]byte{shareCodeVersion, 1, 2, 3}), "corrupt payload"},
+		{"not a deck", rawShareCode(shareCodeVersion, []byte(`[1,2]`)), "invalid share code"},
+	}
+	for _, tt := range tests {
+		_, err := decodeDeck(tt.code)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got error %v, want one mentioning %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestDecodeDeckBoundsDecompression(t *testing.T) {
+	saved := maxBodyBytes
+	defer func() { maxBodyBytes = saved }()
+	maxBodyBytes = 1 << 10
+
+	tests := []struct {
+		name string
+		size int
+		ok   bool
+	}{
+		{"under the cap", 512, true},
+		{"at the cap", 1 << 10, true},
+		{"over the cap", 1<<10 + 1, false},
+		// A few hundred bytes of code that would decompress to 64 MiB.
+		{"gzip bomb", 64 << 20, false},
+	}
+	for _, tt := range tests {
+		// A JSON object padded with spaces to exactly tt.size bytes.
+		payload := append([]byte(`{"game":"mtg"`), bytes.Repeat([]byte(" "), tt.size-len(`{"game":"mtg"}`))...)
+		payload = append(payload, '}')
+		_, err := decodeDeck(rawShareCode(shareCodeVersion, payload))
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v, want ok = %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestDecodeShareCode(t *testing.T) {
+	deck := &Deck{Game: "mtg", Name: "Shared", Cards: []DeckCard{{Name: "Opt", Count: 4}}}
+	code, _ := encodeDeck(deck)
+	tests := []struct {
+		name, source, input string
+		ok                  bool
+	}{
+		{"bare code", "", code, true},
+		{"padded code", "", "  " + code + "\n", true},
+		{"share URL", "", "https://decks.example.org/viewer/?code=" + code, true},
+		{"explicit source", "deckbuilder", code, true},
+		{"unknown source", "moxfield", code, false},
+		{"URL without a code", "", "https://decks.example.org/viewer/?deck=x", false},
+		{"garbage", "", "!!!", false},
+	}
+	for _, tt := range tests {
+		got, err := decodeShareCode(tt.source, tt.input)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v, want ok = %v", tt.name, err, tt.ok)
+			continue
+		}
+		if err == nil && got.Name != deck.Name {
+			t.Errorf("%s: decoded %q, want %q", tt.name, got.Name, deck.Name)
+		}
+	}
+}
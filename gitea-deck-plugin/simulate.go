@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// maxSimTrials caps the trials a single simulation may run.
+const maxSimTrials = 100000
+
+// maxSimDeckSize caps the main deck a simulation shuffles, since every
+// worker holds a copy of it. It is well above any format's deck size.
+const maxSimDeckSize = 1000
+
+// SimTarget is satisfied once at least Min copies of any of Cards have been
+// seen.
+type SimTarget struct {
+	Cards []string `json:"cards"`
+	Min   int      `json:"min"`
+}
+
+// SimParams describes a draw simulation. Hand is the opening hand size and
+// Turns the number of turns drawn after it; on the play there is no draw on
+// turn 1. Seed makes a run reproducible; 0 picks a random seed.
+type SimParams struct {
+	Hand    int         `json:"hand"`
+	Turns   int         `json:"turns"`
+	OnPlay  bool        `json:"onPlay"`
+	Targets []SimTarget `json:"targets"`
+	Trials  int         `json:"trials"`
+	Seed    int64       `json:"seed,omitempty"`
+}
+
+// SimResult holds the chance of every target being satisfied by each turn.
+// ByTurn[0] is the opening hand.
+type SimResult struct {
+	Trials   int       `json:"trials"`
+	DeckSize int       `json:"deckSize"`
+	ByTurn   []float64 `json:"byTurn"`
+	Warnings []string  `json:"warnings"`
+}
+
+// simulateDraws shuffles the main deck params.Trials times, spread across
+// one worker per CPU, and counts the trials in which every target was hit
+// by each turn. Target card names are compared after normalization.
+func simulateDraws(deck *Deck, params SimParams) SimResult {
+	result := SimResult{Trials: params.Trials, DeckSize: deckSize(deck), Warnings: []string{}}
+
+	// library holds one entry per card copy: the targets that copy counts
+	// towards.
+	var library [][]int
+	found := make([]map[string]bool, len(params.Targets))
+	for _, card := range deck.Cards {
+		key := cardKey(deck.Game, card)
+		var targets []int
+		for i, target := range params.Targets {
+			for _, name := range target.Cards {
+				if normalizeName(deck.Game, name) == key {
+					targets = append(targets, i)
+					if found[i] == nil {
+						found[i] = map[string]bool{}
+					}
+					found[i][name] = true
+					break
+				}
+			}
+		}
+		for n := 0; n < card.Count; n++ {
+			library = append(library, targets)
+		}
+	}
+	for i, target := range params.Targets {
+		for _, name := range target.Cards {
+			if !found[i][name] {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("%s is not in the main deck", name))
+			}
+		}
+	}
+
+	// seen[t] is how many cards have been seen by turn t.
+	seen := make([]int, params.Turns+1)
+	for t := range seen {
+		n := params.Hand + t
+		if params.OnPlay && t > 0 {
+			n--
+		}
+		if n > len(library) {
+			n = len(library)
+		}
+		seen[t] = n
+	}
+
+	seed := params.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	workers := runtime.GOMAXPROCS(0)
+	if workers > params.Trials {
+		workers = params.Trials
+	}
+	hits := make([]int, params.Turns+1)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		trials := params.Trials / workers
+		if w < params.Trials%workers {
+			trials++
+		}
+		wg.Add(1)
+		go func(rng *rand.Rand, trials int) {
+			defer wg.Done()
+			local := make([]int, len(hits))
+			order := make([]int, len(library))
+			counts := make([]int, len(params.Targets))
+			for n := 0; n < trials; n++ {
+				for i := range order {
+					order[i] = i
+				}
+				for i := range counts {
+					counts[i] = 0
+				}
+				drawn := 0
+				for t, upto := range seen {
+					// Partial Fisher-Yates: only the cards seen are shuffled.
+					for ; drawn < upto; drawn++ {
+						j := drawn + rng.Intn(len(order)-drawn)
+						order[drawn], order[j] = order[j], order[drawn]
+						for _, target := range library[order[drawn]] {
+							counts[target]++
+						}
+					}
+					if targetsMet(params.Targets, counts) {
+						for ; t < len(local); t++ {
+							local[t]++
+						}
+						break
+					}
+				}
+			}
+			mu.Lock()
+			for t, n := range local {
+				hits[t] += n
+			}
+			mu.Unlock()
+		}(rand.New(rand.NewSource(seed+int64(w))), trials)
+	}
+	wg.Wait()
+
+	result.ByTurn = make([]float64, len(hits))
+	for t, n := range hits {
+		result.ByTurn[t] = float64(n) / float64(params.Trials)
+	}
+	return result
+}
+
+func targetsMet(targets []SimTarget, counts []int) bool {
+	for i, target := range targets {
+		if counts[i] < target.Min {
+			return false
+		}
+	}
+	return true
+}
+
+type simulateRequest struct {
+	Deck Deck `json:"deck"`
+	SimParams
+}
+
+func simulateHandler(w http.ResponseWriter, r *http.Request) {
+	req := simulateRequest{SimParams: SimParams{Hand: 7, Turns: 5, Trials: 10000}}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	params := req.SimParams
+	switch {
+	case params.Hand <= 0 || params.Hand > 20:
+		http.Error(w, "hand must be between 1 and 20", http.StatusBadRequest)
+		return
+	case params.Turns < 0 || params.Turns > 30:
+		http.Error(w, "turns must be between 0 and 30", http.StatusBadRequest)
+		return
+	case params.Trials <= 0 || params.Trials > maxSimTrials:
+		http.Error(w, fmt.Sprintf("trials must be between 1 and %d", maxSimTrials), http.StatusBadRequest)
+		return
+	case len(params.Targets) == 0:
+		http.Error(w, "at least one target is required", http.StatusBadRequest)
+		return
+	}
+	for i := range params.Targets {
+		if len(params.Targets[i].Cards) == 0 {
+			http.Error(w, "every target needs at least one card", http.StatusBadRequest)
+			return
+		}
+		if params.Targets[i].Min <= 0 {
+			params.Targets[i].Min = 1
+		}
+	}
+	total := 0
+	for _, card := range req.Deck.Cards {
+		if card.Count < 0 {
+			http.Error(w, fmt.Sprintf("%s has a negative count", displayName(card)), http.StatusBadRequest)
+			return
+		}
+		if total += card.Count; total > maxSimDeckSize {
+			http.Error(w, fmt.Sprintf("the main deck has more than %d cards", maxSimDeckSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+	}
+	if size := deckSize(&req.Deck); params.Hand > size {
+		http.Error(w, fmt.Sprintf("hand size %d is larger than the deck (%d cards)", params.Hand, size), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(simulateDraws(&req.Deck, params))
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// atLeast is the exact chance of seeing min or more of copies cards among
+// seen cards of a size-card deck.
+func atLeast(size, copies, seen, min int) float64 {
+	p := 0.0
+	for k := min; k <= copies && k <= seen; k++ {
+		p += hypergeometric(size, copies, seen, k)
+	}
+	return p
+}
+
+func TestSimulateDraws(t *testing.T) {
+	burn := &Deck{Game: "mtg", Cards: []DeckCard{
+		{Name: "Lightning Bolt", Count: 4},
+		{Name: "Chain Lightning", Count: 4},
+		{Name: "Mountain", Count: 52},
+	}}
+	tiny := &Deck{Game: "mtg", Cards: []DeckCard{{Name: "Lightning Bolt", Count: 4}, {Name: "Mountain", Count: 6}}}
+	tests := []struct {
+		name     string
+		deck     *Deck
+		params   SimParams
+		want     []float64
+		warnings int
+	}{
+		{"on the draw", burn,
+			SimParams{Hand: 7, Turns: 2, Targets: []SimTarget{{Cards: []string{"Lightning Bolt"}, Min: 1}}},
+			[]float64{atLeast(60, 4, 7, 1), atLeast(60, 4, 8, 1), atLeast(60, 4, 9, 1)}, 0},
+		{"on the play", burn,
+			SimParams{Hand: 7, Turns: 2, OnPlay: true, Targets: []SimTarget{{Cards: []string{"Lightning Bolt"}, Min: 1}}},
+			[]float64{atLeast(60, 4, 7, 1), atLeast(60, 4, 7, 1), atLeast(60, 4, 8, 1)}, 0},
+		{"two copies", burn,
+			SimParams{Hand: 7, Turns: 1, Targets: []SimTarget{{Cards: []string{"Lightning Bolt"}, Min: 2}}},
+			[]float64{atLeast(60, 4, 7, 2), atLeast(60, 4, 8, 2)}, 0},
+		{"any of two cards, normalized", burn,
+			SimParams{Hand: 7, Turns: 0, Targets: []SimTarget{{Cards: []string{"lightning bolt", "Chain Lightning"}, Min: 1}}},
+			[]float64{atLeast(60, 8, 7, 1)}, 0},
+		{"card not in the deck", burn,
+			SimParams{Hand: 7, Turns: 1, Targets: []SimTarget{{Cards: []string{"Lava Spike"}, Min: 1}}},
+			[]float64{0, 0}, 1},
+		{"draws past the deck", tiny,
+			SimParams{Hand: 7, Turns: 5, Targets: []SimTarget{{Cards: []string{"Lightning Bolt"}, Min: 4}}},
+			[]float64{atLeast(10, 4, 7, 4), atLeast(10, 4, 8, 4), atLeast(10, 4, 9, 4), 1, 1, 1}, 0},
+	}
+	for _, tt := range tests {
+		tt.params.Trials = 50000
+		tt.params.Seed = 1
+		got := simulateDraws(tt.deck, tt.params)
+		if len(got.ByTurn) != len(tt.want) {
+			t.Errorf("%s: %d turns, want %d", tt.name, len(got.ByTurn), len(tt.want))
+			continue
+		}
+		for turn, p := range got.ByTurn {
+			if math.Abs(p-tt.want[turn]) > 0.01 {
+				t.Errorf("%s: turn %d: got %.4f, want %.4f", tt.name, turn, p, tt.want[turn])
+			}
+		}
+		if len(got.Warnings) != tt.warnings {
+			t.Errorf("%s: warnings %v, want %d", tt.name, got.Warnings, tt.warnings)
+		}
+	}
+}
+
+func TestSimulateDrawsSeed(t *testing.T) {
+	deck := &Deck{Game: "mtg", Cards: []DeckCard{{Name: "Opt", Count: 4}, {Name: "Island", Count: 56}}}
+	params := SimParams{Hand: 7, Turns: 5, Trials: 1000, Seed: 42, Targets: []SimTarget{{Cards: []string{"Opt"}, Min: 1}}}
+	a, b := simulateDraws(deck, params), simulateDraws(deck, params)
+	if fmt.Sprint(a.ByTurn) != fmt.Sprint(b.ByTurn) {
+		t.Errorf("same seed, different results: %v and %v", a.ByTurn, b.ByTurn)
+	}
+}
+
+func TestSimulateHandler(t *testing.T) {
+	deck := func(counts ...int) Deck {
+		d := Deck{Game: "mtg"}
+		for i, n := range counts {
+			d.Cards = append(d.Cards, DeckCard{Name: fmt.Sprintf("Card %d", i), Count: n})
+		}
+		return d
+	}
+	target := []SimTarget{{Cards: []string{"Card 0"}}}
+	tests := []struct {
+		name string
+		req  simulateRequest
+		want int
+	}{
+		{"valid", simulateRequest{Deck: deck(4, 56), SimParams: SimParams{Targets: target}}, http.StatusOK},
+		{"largest deck", simulateRequest{Deck: deck(4, maxSimDeckSize-4), SimParams: SimParams{Targets: target, Trials: 10}}, http.StatusOK},
+		{"deck too large", simulateRequest{Deck: deck(4, maxSimDeckSize-3), SimParams: SimParams{Targets: target}}, http.StatusRequestEntityTooLarge},
+		{"overflowing count", simulateRequest{Deck: deck(math.MaxInt, 1), SimParams: SimParams{Targets: target}}, http.StatusRequestEntityTooLarge},
+		{"negative count", simulateRequest{Deck: deck(4, -56), SimParams: SimParams{Targets: target}}, http.StatusBadRequest},
+		{"hand larger than the deck", simulateRequest{Deck: deck(4), SimParams: SimParams{Targets: target}}, http.StatusBadRequest},
+		{"no targets", simulateRequest{Deck: deck(4, 56)}, http.StatusBadRequest},
+		{"target without cards", simulateRequest{Deck: deck(4, 56), SimParams: SimParams{Targets: []SimTarget{{Min: 1}}}}, http.StatusBadRequest},
+		{"too many trials", simulateRequest{Deck: deck(4, 56), SimParams: SimParams{Targets: target, Trials: maxSimTrials + 1}}, http.StatusBadRequest},
+		{"too many turns", simulateRequest{Deck: deck(4, 56), SimParams: SimParams{Targets: target, Turns: 31}}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if tt.req.Hand == 0 {
+			tt.req.Hand = 7
+		}
+		if tt.req.Trials == 0 {
+			tt.req.Trials = 100
+		}
+		body, _ := json.Marshal(tt.req)
+		rec := httptest.NewRecorder()
+		simulateHandler(rec, httptest.NewRequest(http.MethodPost, "/simulate", strings.NewReader(string(body))))
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, rec.Code, tt.want, strings.TrimSpace(rec.Body.String()))
+		}
+	}
+}
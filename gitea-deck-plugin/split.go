@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// curveTargets is the share of a limited maindeck we aim to fill at each
+// mana value, with 6 standing for "6 or more".
+var curveTargets = map[int]float64{
+	1: 0.05,
+	2: 0.25,
+	3: 0.25,
+	4: 0.20,
+	5: 0.15,
+	6: 0.10,
+}
+
+// maxPoolCards is the largest pool, in copies, the splitter accepts. It is
+// well above a sealed pool or a cube draft.
+const maxPoolCards = 1000
+
+// limitedLandShare is the share of a limited maindeck given to lands: 17
+// of 40.
+const limitedLandShare = 17.0 / 40
+
+// basicLandFor names the basic land of each color.
+var basicLandFor = map[string]string{
+	"W": "Plains", "U": "Island", "B": "Swamp", "R": "Mountain", "G": "Forest",
+}
+
+func splitDeckHandler(w http.ResponseWriter, r *http.Request) {
+	size := 40
+	if v := r.URL.Query().Get("maindeck"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxPoolCards {
+			http.Error(w, fmt.Sprintf("maindeck must be a positive integer up to %d", maxPoolCards), http.StatusBadRequest)
+			return
+		}
+		size = n
+	}
+
+	var pool Deck
+	if err := json.NewDecoder(r.Body).Decode(&pool); err != nil {
+		http.Error(w, fmt.Sprintf("invalid deck JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	total := 0
+	for _, card := range pool.Cards {
+		if card.Count < 0 {
+			http.Error(w, fmt.Sprintf("%s has a negative count", displayName(card)), http.StatusBadRequest)
+			return
+		}
+		if total += card.Count; total > maxPoolCards {
+			http.Error(w, fmt.Sprintf("pool has more than %d cards", maxPoolCards), http.StatusRequestEntityTooLarge)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suggestSplit(&pool, size))
+}
+
+// suggestSplit proposes a maindeck of size cards from the pool in
+// pool.Cards, putting everything else in the sideboard. It favours the two
+// colors with the most cards and sets aside limitedLandShare of the
+// maindeck for lands: the pool's own lands first, then basic lands of the
+// two colors in proportion to the spells played. The rest is filled with a
+// limited-style mana curve from the on-color and colorless spells. Cards
+// without color data are treated as colorless, and cards without type data
+// as spells unless they are basic lands.
+func suggestSplit(pool *Deck, size int) *Deck {
+	colorWeight := map[string]int{}
+	for _, card := range pool.Cards {
+		for _, c := range card.Colors {
+			colorWeight[c] += card.Count
+		}
+	}
+
+	colors := make([]string, 0, len(colorWeight))
+	for c := range colorWeight {
+		colors = append(colors, c)
+	}
+	sort.Slice(colors, func(i, j int) bool {
+		if colorWeight[colors[i]] != colorWeight[colors[j]] {
+			return colorWeight[colors[i]] > colorWeight[colors[j]]
+		}
+		return colors[i] < colors[j]
+	})
+	main := map[string]bool{}
+	for i := 0; i < len(colors) && i < 2; i++ {
+		main[colors[i]] = true
+	}
+
+	offColor := func(card DeckCard) int {
+		n := 0
+		for _, c := range card.Colors {
+			if !main[c] {
+				n++
+			}
+		}
+		return n
+	}
+	isLand := func(card DeckCard) bool {
+		return hasType(card, "land") || isBasicLand(card.Name)
+	}
+
+	// Order entries by fewest off-color symbols, then by mana value so
+	// cheaper cards are considered first within each curve bucket.
+	entries := make([]DeckCard, 0, len(pool.Cards))
+	for _, card := range pool.Cards {
+		if card.Count > 0 {
+			entries = append(entries, card)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		oi, oj := offColor(entries[i]), offColor(entries[j])
+		if oi != oj {
+			return oi < oj
+		}
+		return entries[i].CMC < entries[j].CMC
+	})
+
+	// picked is the number of copies of each entry in the maindeck.
+	picked := make([]int, len(entries))
+	taken := 0
+	take := func(i, n int) int {
+		if n > entries[i].Count-picked[i] {
+			n = entries[i].Count - picked[i]
+		}
+		if n > size-taken {
+			n = size - taken
+		}
+		if n < 0 {
+			n = 0
+		}
+		picked[i] += n
+		taken += n
+		return n
+	}
+	bucket := func(card DeckCard) int {
+		b := int(card.CMC)
+		if b < 1 {
+			b = 1
+		}
+		if b > 6 {
+			b = 6
+		}
+		return b
+	}
+
+	landSlots := int(math.Round(limitedLandShare * float64(size)))
+	spellSlots := size - landSlots
+
+	// First pass: fill each curve bucket with on-color spells.
+	filled := map[int]int{}
+	for i, card := range entries {
+		if taken >= spellSlots || isLand(card) || offColor(card) > 0 {
+			continue
+		}
+		b := bucket(card)
+		want := int(math.Round(curveTargets[b]*float64(spellSlots))) - filled[b]
+		if want > spellSlots-taken {
+			want = spellSlots - taken
+		}
+		if want > 0 {
+			filled[b] += take(i, want)
+		}
+	}
+
+	// Second pass: top up the spells with the best remaining ones in sort
+	// order.
+	for i, card := range entries {
+		if taken >= spellSlots {
+			break
+		}
+		if !isLand(card) {
+			take(i, spellSlots-taken)
+		}
+	}
+
+	// Lands: the pool's on-color lands, then basics for the rest.
+	for i, card := range entries {
+		if isLand(card) && offColor(card) == 0 {
+			take(i, size-taken)
+		}
+	}
+
+	result := &Deck{
+		Game:     pool.Game,
+		Format:   pool.Format,
+		Name:     pool.Name,
+		Metadata: pool.Metadata,
+	}
+	for i, card := range entries {
+		if picked[i] > 0 {
+			card.Count = picked[i]
+			result.Cards = append(result.Cards, card)
+		}
+		if rest := entries[i].Count - picked[i]; rest > 0 {
+			card.Count = rest
+			result.Sideboard = append(result.Sideboard, card)
+		}
+	}
+	result.Cards = append(result.Cards, basicLands(result.Cards, colors, size-taken)...)
+	return result
+}
+
+// basicLands splits n basic lands between the first two of colors in
+// proportion to the colored symbols of the spells in cards. It returns
+// nothing when there are no colors to choose from.
+func basicLands(cards []DeckCard, colors []string, n int) []DeckCard {
+	if n <= 0 || len(colors) == 0 {
+		return nil
+	}
+	if len(colors) > 2 {
+		colors = colors[:2]
+	}
+	symbols := map[string]int{}
+	total := 0
+	for _, card := range cards {
+		for _, c := range card.Colors {
+			if c == colors[0] || len(colors) > 1 && c == colors[1] {
+				symbols[c] += card.Count
+				total += card.Count
+			}
+		}
+	}
+
+	var lands []DeckCard
+	left := n
+	for i, c := range colors {
+		count := left
+		if i == 0 && len(colors) > 1 && total > 0 {
+			count = int(math.Round(float64(n) * float64(symbols[c]) / float64(total)))
+		} else if i == 0 && len(colors) > 1 {
+			count = n / 2
+		}
+		if name, ok := basicLandFor[c]; ok && count > 0 {
+			lands = append(lands, DeckCard{Name: name, Count: count, Type: "Basic Land"})
+		}
+		left -= count
+	}
+	return lands
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// sealedPool returns a pool with spells spread over the curve: per color,
+// count copies of one card at each mana value from 1 to 6.
+func sealedPool(colors map[string]int) *Deck {
+	pool := &Deck{Game: "mtg", Format: "pool"}
+	for c, count := range colors {
+		for cmc := 1; cmc <= 6; cmc++ {
+			pool.Cards = append(pool.Cards, DeckCard{
+				Name:   fmt.Sprintf("%s spell %d", c, cmc),
+				Count:  count,
+				CMC:    float64(cmc),
+				Colors: []string{c},
+				Type:   "Creature",
+			})
+		}
+	}
+	return pool
+}
+
+func TestSuggestSplit(t *testing.T) {
+	withLands := sealedPool(map[string]int{"W": 3, "U": 3, "B": 1})
+	withLands.Cards = append(withLands.Cards,
+		DeckCard{Name: "Azorius Guildgate", Count: 2, Type: "Land", Colors: []string{}},
+		DeckCard{Name: "Plains", Count: 3, Type: "Basic Land — Plains"})
+	tests := []struct {
+		name   string
+		pool   *Deck
+		size   int
+		main   int    // expected maindeck size
+		lands  int    // expected lands in the maindeck
+		colors string // colors allowed in maindeck spells
+	}{
+		{"two colors", sealedPool(map[string]int{"W": 3, "U": 3, "B": 1}), 40, 40, 17, "WU"},
+		{"pool lands first", withLands, 40, 40, 17, "WU"},
+		{"bigger maindeck", sealedPool(map[string]int{"R": 4, "G": 4}), 60, 60, 26, "RG"},
+		{"small pool", sealedPool(map[string]int{"R": 1}), 40, 40, 34, "R"},
+	}
+	for _, tt := range tests {
+		deck := suggestSplit(tt.pool, tt.size)
+		main, lands := 0, 0
+		for _, card := range deck.Cards {
+			main += card.Count
+			if hasType(card, "land") || isBasicLand(card.Name) {
+				lands += card.Count
+				continue
+			}
+			for _, c := range card.Colors {
+				if !strings.Contains(tt.colors, c) {
+					t.Errorf("%s: off-color %s in the maindeck", tt.name, card.Name)
+				}
+			}
+		}
+		if tt.pool == withLands && !strings.Contains(fmt.Sprint(deck.Cards), "Azorius Guildgate") {
+			t.Errorf("%s: the pool's on-color land wasn't played", tt.name)
+		}
+		if main != tt.main || lands != tt.lands {
+			t.Errorf("%s: maindeck of %d with %d lands, want %d with %d (%v)", tt.name, main, lands, tt.main, tt.lands, deck.Cards)
+		}
+
+		// Every pool card ends up in the maindeck or the sideboard, and
+		// only added basics are new.
+		copies := map[string]int{}
+		for _, card := range append(append([]DeckCard{}, deck.Cards...), deck.Sideboard...) {
+			copies[card.Name] += card.Count
+		}
+		for _, card := range tt.pool.Cards {
+			if copies[card.Name] < card.Count {
+				t.Errorf("%s: %s has %d copies after the split, want %d", tt.name, card.Name, copies[card.Name], card.Count)
+			}
+			if !isBasicLand(card.Name) && copies[card.Name] != card.Count {
+				t.Errorf("%s: %s has %d copies after the split, want %d", tt.name, card.Name, copies[card.Name], card.Count)
+			}
+		}
+	}
+}
+
+func TestBasicLands(t *testing.T) {
+	tests := []struct {
+		name   string
+		cards  []DeckCard
+		colors []string
+		n      int
+		want   string
+	}{
+		{"even split", []DeckCard{{Count: 4, Colors: []string{"W"}}, {Count: 4, Colors: []string{"U"}}}, []string{"W", "U"}, 16, "[8 Plains 8 Island]"},
+		{"by symbols", []DeckCard{{Count: 6, Colors: []string{"R"}}, {Count: 2, Colors: []string{"G"}}}, []string{"R", "G"}, 16, "[12 Mountain 4 Forest]"},
+		{"one color", []DeckCard{{Count: 6, Colors: []string{"B"}}}, []string{"B"}, 17, "[17 Swamp]"},
+		{"no symbols", nil, []string{"W", "G"}, 17, "[8 Plains 9 Forest]"},
+		{"only the top two colors", []DeckCard{{Count: 5, Colors: []string{"W"}}, {Count: 5, Colors: []string{"U"}}, {Count: 9, Colors: []string{"B"}}}, []string{"W", "U", "B"}, 10, "[5 Plains 5 Island]"},
+		{"no colors", nil, nil, 17, "[]"},
+		{"nothing to add", nil, []string{"W"}, 0, "[]"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, land := range basicLands(tt.cards, tt.colors, tt.n) {
+			got = append(got, fmt.Sprintf("%d %s", land.Count, land.Name))
+		}
+		if s := "[" + strings.Join(got, " ") + "]"; s != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, s, tt.want)
+		}
+	}
+}
+
+func TestSplitDeckHandlerLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		body  string
+		want  int
+	}{
+		{"sealed pool", "", `{"game":"mtg","cards":[{"name":"Opt","count":90}]}`, http.StatusOK},
+		{"pool too large", "", fmt.Sprintf(`{"game":"mtg","cards":[{"name":"Opt","count":%d}]}`, maxPoolCards+1), http.StatusRequestEntityTooLarge},
+		{"overflowing count", "", `{"game":"mtg","cards":[{"name":"Opt","count":9223372036854775807},{"name":"Ponder","count":1}]}`, http.StatusRequestEntityTooLarge},
+		{"negative count", "", `{"game":"mtg","cards":[{"name":"Opt","count":-5}]}`, http.StatusBadRequest},
+		{"maindeck too large", "?maindeck=1001", `{"game":"mtg","cards":[]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		splitDeckHandler(rec, httptest.NewRequest(http.MethodPost, "/split"+tt.query, strings.NewReader(tt.body)))
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, rec.Code, tt.want, strings.TrimSpace(rec.Body.String()))
+		}
+	}
+}
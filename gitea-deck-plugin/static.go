@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"io/fs"
+	"net/http"
+	"os"
+	"time"
+)
+
+//go:embed static
+var embeddedStatic embed.FS
+
+// staticFiles holds the viewer's static assets: the embedded copy, or the
+// -static-dir directory when one is set.
+var staticFiles fs.FS
+
+// loadStaticFiles picks the asset source. An empty dir uses the assets
+// embedded in the binary, so it runs from any working directory.
+func loadStaticFiles(dir string) error {
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			return err
+		}
+		staticFiles = os.DirFS(dir)
+		return nil
+	}
+	sub, err := fs.Sub(embeddedStatic, "static")
+	if err != nil {
+		return err
+	}
+	staticFiles = sub
+	return nil
+}
+
+// viewerHandler serves viewer.html for every /viewer/ path; the viewer reads
+// the deck from the query string.
+func viewerHandler(w http.ResponseWriter, r *http.Request) {
+	page, err := fs.ReadFile(staticFiles, "viewer.html")
+	if err != nil {
+		http.Error(w, "viewer not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	http.ServeContent(w, r, "viewer.html", time.Time{}, bytes.NewReader(page))
+}
+
+// staticHandler serves the raw static assets under /static/.
+func staticHandler() http.Handler {
+	return http.StripPrefix("/static/", http.FileServer(http.FS(staticFiles)))
+}
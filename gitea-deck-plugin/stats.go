@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// DeckStats summarises the size of each zone of a deck.
+type DeckStats struct {
+	TotalCards      int `json:"totalCards"`
+	UniqueCards     int `json:"uniqueCards"`
+	SideboardCards  int `json:"sideboardCards"`
+	MaybeboardCards int `json:"maybeboardCards"`
+}
+
+// deckStats counts a deck's zones. UniqueCards is the number of distinct
+// main deck cards, so a card split over several entries counts once.
+func deckStats(deck *Deck) DeckStats {
+	var stats DeckStats
+	unique := map[string]bool{}
+	for _, card := range deck.Cards {
+		stats.TotalCards += card.Count
+		unique[cardKey(deck.Game, card)] = true
+	}
+	stats.UniqueCards = len(unique)
+	for _, card := range deck.Sideboard {
+		stats.SideboardCards += card.Count
+	}
+	for _, card := range deck.Maybeboard {
+		stats.MaybeboardCards += card.Count
+	}
+	return stats
+}
+
+func deckStatsHandler(w http.ResponseWriter, r *http.Request) {
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deckStats(deck))
+}
+
+// countHistogram maps each copy count to the number of distinct main deck
+// cards played at that count. Entries for the same card are combined first.
+func countHistogram(deck *Deck) map[int]int {
+	return histogram(deck.Game, deck.Cards)
+}
+
+func histogram(game string, cards []DeckCard) map[int]int {
+	copies := map[string]int{}
+	for _, card := range cards {
+		copies[cardKey(game, card)] += card.Count
+	}
+	hist := map[int]int{}
+	for _, n := range copies {
+		hist[n]++
+	}
+	return hist
+}
+
+type countHistogramResponse struct {
+	Cards     map[int]int `json:"cards"`
+	Sideboard map[int]int `json:"sideboard,omitempty"`
+}
+
+func countHistogramHandler(w http.ResponseWriter, r *http.Request) {
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := countHistogramResponse{Cards: countHistogram(deck)}
+	if r.URL.Query().Get("include-sideboard") == "true" {
+		resp.Sideboard = histogram(deck.Game, deck.Sideboard)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
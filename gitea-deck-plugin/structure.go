@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// gameName is the form of a valid game identifier, such as "mtg".
+var gameName = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// validateStructure checks only that a deck is well formed: a valid game,
+// a non-empty main deck, cards with a name or ID and positive counts, and
+// parseable metadata timestamps. No format rules run, so it is a cheap
+// first pass before validateDeck. Single-card zones such as the commander
+// may leave the count out.
+func validateStructure(deck *Deck) ValidationResult {
+	result := newValidationResult()
+
+	switch {
+	case deck.Game == "":
+		result.addError(CodeMissingGame, "")
+	case !gameName.MatchString(deck.Game):
+		result.addError(CodeInvalidGame, "", deck.Game)
+	}
+	if len(deck.Cards) == 0 {
+		result.addError(CodeEmptyDeck, "")
+	}
+
+	checkCards := func(cards []DeckCard, min int) {
+		for _, card := range cards {
+			if strings.TrimSpace(card.Name) == "" && card.ID == "" {
+				result.addError(CodeUnidentifiedCard, "")
+				continue
+			}
+			if card.Count < min {
+				result.addError(CodeInvalidCount, displayName(card), displayName(card), card.Count)
+			}
+		}
+	}
+	for _, zone := range [][]DeckCard{deck.Cards, deck.Sideboard, deck.Maybeboard, deck.Commanders, deck.Battlefields, deck.Runes} {
+		checkCards(zone, 1)
+	}
+	for _, card := range []*DeckCard{deck.Commander, deck.Companion, deck.Oathbreaker, deck.SignatureSpell, deck.Legend, deck.Battlefield} {
+		if card != nil {
+			checkCards([]DeckCard{*card}, 0)
+		}
+	}
+
+	for _, field := range []struct{ name, value string }{
+		{"created", deck.Metadata.Created},
+		{"updated", deck.Metadata.Updated},
+	} {
+		if field.value != "" && !isTimestamp(field.value) {
+			result.addError(CodeInvalidTimestamp, "", field.name, field.value)
+		}
+	}
+	return result
+}
+
+// isTimestamp reports whether s is in one of the timestampLayouts.
+func isTimestamp(s string) bool {
+	for _, layout := range timestampLayouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func validateStructureHandler(w http.ResponseWriter, r *http.Request) {
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := validateStructure(deck)
+	localizeResult(w, r, &result)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,34 @@
+package main
+
+import "strings"
+
+// strictTags makes a tag outside rules.AllowedTags an error instead of a
+// warning.
+var strictTags bool
+
+// normalizeTag is the form tags are compared in.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// checkTags flags every tag in Metadata.Tags that isn't in the configured
+// vocabulary. Decks aren't checked when no vocabulary is configured.
+func checkTags(deck *Deck, result *ValidationResult) {
+	if len(rules.AllowedTags) == 0 {
+		return
+	}
+	allowed := map[string]bool{}
+	for _, tag := range rules.AllowedTags {
+		allowed[normalizeTag(tag)] = true
+	}
+	for _, tag := range deck.Metadata.Tags {
+		if allowed[normalizeTag(tag)] {
+			continue
+		}
+		if strictTags {
+			result.addError(CodeTagNotAllowed, "", tag)
+		} else {
+			result.addWarning(CodeTagNotAllowed, "", tag)
+		}
+	}
+}
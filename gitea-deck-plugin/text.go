@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// formatText renders a validation result as a plain-text summary for
+// terminals: the deck's name and size, VALID or INVALID, then the errors
+// and warnings as bulleted lists.
+func formatText(result ValidationResult, deck *Deck) string {
+	var b strings.Builder
+	name := deck.Name
+	if name == "" {
+		name = "(unnamed)"
+	}
+	fmt.Fprintf(&b, "Deck: %s\n", name)
+	fmt.Fprintf(&b, "Cards: %d\n", deckSize(deck))
+	if result.Valid {
+		b.WriteString("VALID\n")
+	} else {
+		b.WriteString("INVALID\n")
+	}
+
+	list := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s:\n", title)
+		for _, item := range items {
+			fmt.Fprintf(&b, "  - %s\n", item)
+		}
+	}
+	list("Errors", result.Errors)
+	list("Warnings", result.Warnings)
+	return b.String()
+}
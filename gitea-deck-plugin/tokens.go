@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// TokenNeed is a token a deck can create and the cards that make it.
+type TokenNeed struct {
+	Token string   `json:"token"`
+	Cards []string `json:"cards"`
+}
+
+func defaultTokenMap() map[string][]string {
+	return map[string][]string{
+		"Adeline, Resplendent Cathar": {"1/1 white Human"},
+		"Bitterblossom":               {"1/1 black Faerie Rogue"},
+		"Dockside Extortionist":       {"Treasure"},
+		"Krenko, Mob Boss":            {"1/1 red Goblin"},
+		"Lingering Souls":             {"1/1 white Spirit"},
+		"Smothering Tithe":            {"Treasure"},
+		"Tireless Provisioner":        {"Food", "Treasure"},
+		"Young Pyromancer":            {"1/1 red Elemental"},
+	}
+}
+
+// tokensNeeded lists the distinct tokens the deck's cards can create
+// according to mapping, a card name to token list map, sorted by token.
+// Cards not in the mapping are ignored.
+func tokensNeeded(deck *Deck, mapping map[string][]string) []TokenNeed {
+	produces := map[string][]string{}
+	for name, tokens := range mapping {
+		produces[normalizeName(deck.Game, name)] = tokens
+	}
+
+	makers := map[string][]string{}
+	seen := map[string]bool{}
+	for _, card := range playedCards(deck) {
+		key := cardKey(deck.Game, card)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		for _, token := range produces[key] {
+			makers[token] = append(makers[token], displayName(card))
+		}
+	}
+
+	needs := make([]TokenNeed, 0, len(makers))
+	for token, cards := range makers {
+		needs = append(needs, TokenNeed{Token: token, Cards: cards})
+	}
+	sort.Slice(needs, func(i, j int) bool { return needs[i].Token < needs[j].Token })
+	return needs
+}
+
+func tokensNeededHandler(w http.ResponseWriter, r *http.Request) {
+	deck, err := readDeck(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokensNeeded(deck, rules.Tokens))
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// validationRecord summarises one validation for the recent-validations log.
+type validationRecord struct {
+	Time      time.Time `json:"time"`
+	Game      string    `json:"game"`
+	Format    string    `json:"format"`
+	Valid     bool      `json:"valid"`
+	Errors    int       `json:"errors"`
+	RequestID string    `json:"requestId,omitempty"`
+}
+
+// validationLog is a fixed-size ring buffer of the most recent validations.
+// It is safe for concurrent use.
+type validationLog struct {
+	mu      sync.Mutex
+	records []validationRecord
+	next    int
+	full    bool
+}
+
+func newValidationLog(size int) *validationLog {
+	return &validationLog{records: make([]validationRecord, size)}
+}
+
+// add records a validation, overwriting the oldest record once the buffer
+// is full.
+func (l *validationLog) add(rec validationRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.records) == 0 {
+		return
+	}
+	l.records[l.next] = rec
+	l.next = (l.next + 1) % len(l.records)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// recent returns the buffered records, newest first.
+func (l *validationLog) recent() []validationRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := l.next
+	if l.full {
+		n = len(l.records)
+	}
+	out := make([]validationRecord, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, l.records[(l.next-i+len(l.records))%len(l.records)])
+	}
+	return out
+}
+
+// recentValidations is the log validateDeckHandler records into. Its size
+// is set by -recent-validations.
+var recentValidations = newValidationLog(100)
+
+// adminToken guards the /admin endpoints. They aren't served when it is
+// empty.
+var adminToken string
+
+// requireAdminToken rejects requests that don't carry adminToken as a
+// bearer token.
+func requireAdminToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func recentValidationsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(recentValidations.recent())
+}
+
+// formatStats counts validations and how many were invalid.
+type formatStats struct {
+	Game        string  `json:"game"`
+	Format      string  `json:"format"`
+	Validations int     `json:"validations"`
+	Invalid     int     `json:"invalid"`
+	InvalidRate float64 `json:"invalidRate"`
+}
+
+// validationStats aggregates validations per game and format since the
+// server started. It is safe for concurrent use.
+type validationStats struct {
+	mu     sync.Mutex
+	counts map[[2]string]*formatStats
+}
+
+// otherBucket is the game or format unknown games and formats are counted
+// under, so clients can't grow the stats without bound.
+const otherBucket = "other"
+
+// statsKey is the game and format a validation is counted under. Games
+// the validator has rules for, the -allowed-games and the card database's
+// games are kept, as are pools and the known MTG formats, after resolving
+// aliases; anything else is otherBucket.
+func statsKey(game, format string) [2]string {
+	game = strings.ToLower(strings.TrimSpace(game))
+	known := knownGames[game]
+	for _, g := range allowedGames {
+		known = known || g == game
+	}
+	if !known && cardDB != nil {
+		for _, g := range cardDB.Games() {
+			known = known || g == game
+		}
+	}
+	if !known {
+		game = otherBucket
+	}
+
+	format = canonicalFormat(format)
+	if format != "" && format != "pool" && !isConstructedFormat(format) {
+		format = otherBucket
+	}
+	return [2]string{game, format}
+}
+
+// add counts a validation against its game and format.
+func (s *validationStats) add(rec validationRecord) {
+	key := statsKey(rec.Game, rec.Format)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.counts[key]
+	if stats == nil {
+		stats = &formatStats{Game: key[0], Format: key[1]}
+		s.counts[key] = stats
+	}
+	stats.Validations++
+	if !rec.Valid {
+		stats.Invalid++
+	}
+}
+
+// snapshot returns the counts, most validated first, with each invalid
+// rate filled in.
+func (s *validationStats) snapshot() []formatStats {
+	s.mu.Lock()
+	out := make([]formatStats, 0, len(s.counts))
+	for _, stats := range s.counts {
+		entry := *stats
+		entry.InvalidRate = float64(entry.Invalid) / float64(entry.Validations)
+		out = append(out, entry)
+	}
+	s.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Validations != out[j].Validations {
+			return out[i].Validations > out[j].Validations
+		}
+		if out[i].Game != out[j].Game {
+			return out[i].Game < out[j].Game
+		}
+		return out[i].Format < out[j].Format
+	})
+	return out
+}
+
+// validationCounts is the aggregate validateDeckHandler records into next to
+// recentValidations.
+var validationCounts = &validationStats{counts: map[[2]string]*formatStats{}}
+
+func validationStatsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(validationCounts.snapshot())
+}